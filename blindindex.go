@@ -0,0 +1,65 @@
+package mysql
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrNoBlindIndexKey = errors.New("key provider does not provide a blind index key")
+)
+
+// BlindIndexKeyProvider could be implemented by the KeyProvider to provide the HMAC key of blind indices.
+// Unlike the encryption keys, the key could not be rotated without rebuilding the blind index columns.
+type BlindIndexKeyProvider interface {
+	BlindIndexKey() ([]byte, error)
+}
+
+func (p *StaticKeyProvider) BlindIndexKey() ([]byte, error) {
+	if len(p.BlindKey) == 0 {
+		return nil, ErrNoBlindIndexKey
+	}
+	return p.BlindKey, nil
+}
+
+// blindIndexField returns the companion column of a field with blind index
+func blindIndexField(source *Field) *Field {
+	return &Field{
+		Name:        source.BlindIndex,
+		Type:        "binary(32)",
+		IsNullable:  source.IsNullable,
+		Comment:     "Blind index of " + source.Name,
+		Indices:     []*FieldIndexDecl{{IndexType: INDEX, IndexName: "idx_" + source.BlindIndex}},
+		EntityIndex: source.EntityIndex,
+		blindSource: source,
+	}
+}
+
+// blindIndexValue computes the HMAC-SHA256 of the plain value of the source field, the column name is mixed in,
+// so the same value gives different hashes in different columns.
+func blindIndexValue(source *Field, v reflect.Value) ([]byte, error) {
+	p, e := getKeyProvider()
+	if e != nil {
+		return nil, e
+	}
+	bp, ok := p.(BlindIndexKeyProvider)
+	if !ok {
+		return nil, ErrNoBlindIndexKey
+	}
+	key, e := bp.BlindIndexKey()
+	if e != nil {
+		return nil, e
+	}
+	plain, e := fieldPlainBytes(source, v)
+	if e != nil {
+		return nil, errors.Wrap(e, "Blind index of "+source.Name+" failed")
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(source.Name))
+	mac.Write([]byte{0})
+	mac.Write(plain)
+	return mac.Sum(nil), nil
+}
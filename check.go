@@ -0,0 +1,60 @@
+package mysql
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// Check is a CHECK constraint of a table
+type Check struct {
+	Name string
+	Expr string
+}
+
+// TableChecker could be implemented by the struct type of a schema to declare table level CHECK constraints,
+// which usually involve more than one column. Column level constraints could be declared by the `check` tag option.
+// Constraint names are shared by the whole database, so the names are prefixed by the table name if they are not yet,
+// e.g. `<table>_<name>`, and `<table>_chk_<n>` is used if the name is omitted. Names longer than 64 characters
+// are shortened with a hash.
+type TableChecker interface {
+	TableChecks() []*Check
+}
+
+// checkName returns the name of a check of the table, unique in the database and within the identifier limit
+func checkName(table string, name string) string {
+	if !strings.HasPrefix(name, table+"_") {
+		name = table + "_" + name
+	}
+	if len(name) <= maxIdentifierLength {
+		return name
+	}
+	sum := sha256.Sum256([]byte(name))
+	return name[:maxIdentifierLength-9] + "_" + hex.EncodeToString(sum[:4])
+}
+
+func (ck *Check) Equal(other *Check) bool {
+	if ck.Name != other.Name {
+		return false
+	}
+	return stripBrackets(normalizeSQLExpr(ck.Expr)) == stripBrackets(normalizeSQLExpr(other.Expr))
+}
+
+// stripBrackets removes the brackets surrounding the whole expression
+func stripBrackets(v string) string {
+	for len(v) >= 2 && v[0] == '(' && v[len(v)-1] == ')' {
+		depth := 0
+		for i := 0; i < len(v)-1; i++ {
+			if v[i] == '(' {
+				depth++
+			} else if v[i] == ')' {
+				depth--
+			}
+			if depth == 0 {
+				return v // the first bracket is closed before the end
+			}
+		}
+		v = strings.TrimSpace(v[1 : len(v)-1])
+	}
+	return v
+}
@@ -0,0 +1,36 @@
+package mysql
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckName(t *testing.T) {
+	long := strings.Repeat("x", 70)
+	cases := []struct {
+		table string
+		name  string
+		want  string
+	}{
+		{table: "orders", name: "amount_positive", want: "orders_amount_positive"},
+		{table: "orders", name: "orders_amount_positive", want: "orders_amount_positive"},
+		{table: "orders_202610", name: "orders_amount_positive", want: "orders_202610_orders_amount_positive"},
+		{table: "orders", name: "chk_1", want: "orders_chk_1"},
+	}
+	for _, c := range cases {
+		if got := checkName(c.table, c.name); got != c.want {
+			t.Errorf("%s %s: got %s, want %s", c.table, c.name, got, c.want)
+		}
+	}
+
+	a, b := checkName("orders", long+"a"), checkName("orders", long+"b")
+	if len(a) != maxIdentifierLength || len(b) != maxIdentifierLength {
+		t.Errorf("got lengths %d and %d, want %d", len(a), len(b), maxIdentifierLength)
+	}
+	if a == b || !strings.HasPrefix(a, "orders_x") {
+		t.Errorf("got %s and %s, want distinct names with the table prefix", a, b)
+	}
+	if checkName("orders", long+"a") != a {
+		t.Error("shortened name is not stable")
+	}
+}
@@ -0,0 +1,159 @@
+package mysql
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	cursorKey    []byte
+	cursorKeySet bool // set by SetCursorKey rather than generated
+	cursorKeyMu  sync.RWMutex
+)
+
+// SetCursorKey sets the HMAC key signing the cursors of SelectCursor. A random key is generated at startup,
+// so the key should be set to the same value on all instances if a cursor is passed between them,
+// or if cursors should survive a restart.
+func SetCursorKey(key []byte) {
+	cursorKeyMu.Lock()
+	defer cursorKeyMu.Unlock()
+	cursorKey = key
+	cursorKeySet = key != nil
+}
+
+// hasCursorKey reports whether the cursor key is set by SetCursorKey, so the cursors survive a restart
+func hasCursorKey() bool {
+	cursorKeyMu.RLock()
+	defer cursorKeyMu.RUnlock()
+	return cursorKeySet
+}
+
+func getCursorKey() []byte {
+	cursorKeyMu.RLock()
+	key := cursorKey
+	cursorKeyMu.RUnlock()
+	if key != nil {
+		return key
+	}
+
+	cursorKeyMu.Lock()
+	defer cursorKeyMu.Unlock()
+	if cursorKey == nil {
+		cursorKey = make([]byte, 32)
+		if _, e := rand.Read(cursorKey); e != nil {
+			panic(e)
+		}
+	}
+	return cursorKey
+}
+
+// cursorToken is the payload of a cursor, it is signed so the values could not be changed by the client
+type cursorToken struct {
+	Table    string      `json:"t"`
+	Order    string      `json:"o"` // Order of the page, e.g. "`created_at` DESC,`id`"
+	Backward bool        `json:"b"` // Rows before the values
+	Values   [][2]string `json:"v"` // Values of the order columns as [kind, value]
+}
+
+// encodeCursor returns the token of the cursor: base64(payload).base64(hmac)
+func encodeCursor(c *cursorToken) (string, error) {
+	payload, e := json.Marshal(c)
+	if e != nil {
+		return "", errors.Wrap(e, "Encode cursor failed")
+	}
+	mac := hmac.New(sha256.New, getCursorKey())
+	mac.Write(payload)
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// decodeCursor verifies the token and returns its payload, which should be made for the table and the order
+// of the page, with the values of the n order columns
+func decodeCursor(token string, table string, order string, n int) (*cursorToken, error) {
+	p, s, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+	payload, e := base64.RawURLEncoding.DecodeString(p)
+	if e != nil {
+		return nil, ErrInvalidCursor
+	}
+	sig, e := base64.RawURLEncoding.DecodeString(s)
+	if e != nil {
+		return nil, ErrInvalidCursor
+	}
+	mac := hmac.New(sha256.New, getCursorKey())
+	mac.Write(payload)
+	if !hmac.Equal(sig, mac.Sum(nil)) {
+		return nil, ErrInvalidCursor
+	}
+	c := &cursorToken{}
+	if e := json.Unmarshal(payload, c); e != nil {
+		return nil, ErrInvalidCursor
+	}
+	if c.Table != table || c.Order != order || len(c.Values) != n {
+		return nil, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// cursorValue converts a value passed to the driver into the [kind, value] kept in a cursor
+func cursorValue(v any) ([2]string, error) {
+	switch x := v.(type) {
+	case time.Time:
+		return [2]string{"t", x.Format(time.RFC3339Nano)}, nil
+	case []byte:
+		return [2]string{"b", base64.StdEncoding.EncodeToString(x)}, nil
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Bool:
+		return [2]string{"?", strconv.FormatBool(rv.Bool())}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return [2]string{"i", strconv.FormatInt(rv.Int(), 10)}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return [2]string{"u", strconv.FormatUint(rv.Uint(), 10)}, nil
+	case reflect.Float32, reflect.Float64:
+		return [2]string{"f", strconv.FormatFloat(rv.Float(), 'g', -1, 64)}, nil
+	case reflect.String:
+		return [2]string{"s", rv.String()}, nil
+	}
+	return [2]string{}, errors.New("Unsupported cursor value: " + reflect.TypeOf(v).String())
+}
+
+// argValue is the reverse of cursorValue
+func argValue(v [2]string) (any, error) {
+	var r any
+	var e error
+	switch v[0] {
+	case "t":
+		r, e = time.Parse(time.RFC3339Nano, v[1])
+	case "b":
+		r, e = base64.StdEncoding.DecodeString(v[1])
+	case "?":
+		r, e = strconv.ParseBool(v[1])
+	case "i":
+		r, e = strconv.ParseInt(v[1], 10, 64)
+	case "u":
+		r, e = strconv.ParseUint(v[1], 10, 64)
+	case "f":
+		r, e = strconv.ParseFloat(v[1], 64)
+	case "s":
+		r = v[1]
+	default:
+		return nil, ErrInvalidCursor
+	}
+	if e != nil {
+		return nil, ErrInvalidCursor
+	}
+	return r, nil
+}
@@ -0,0 +1,108 @@
+package mysql
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDecodeCursor(t *testing.T) {
+	SetCursorKey([]byte("test key"))
+	defer SetCursorKey(nil)
+	token, e := encodeCursor(&cursorToken{Table: "orders", Order: "`created_at` DESC,`id` DESC", Values: [][2]string{{"t", "2026-10-19T00:00:00Z"}, {"i", "42"}}})
+	if e != nil {
+		t.Fatal(e)
+	}
+	payload, sig, _ := strings.Cut(token, ".")
+	raw, _ := base64.RawURLEncoding.DecodeString(payload)
+	tampered := base64.RawURLEncoding.EncodeToString(bytes.Replace(raw, []byte("42"), []byte("43"), 1))
+	rawSig, _ := base64.RawURLEncoding.DecodeString(sig)
+	rawSig[0] ^= 1
+
+	cases := []struct {
+		name  string
+		token string
+		table string
+		order string
+		n     int
+		ok    bool
+	}{
+		{name: "valid", token: token, table: "orders", order: "`created_at` DESC,`id` DESC", n: 2, ok: true},
+		{name: "tampered payload", token: tampered + "." + sig, table: "orders", order: "`created_at` DESC,`id` DESC", n: 2},
+		{name: "tampered signature", token: payload + "." + base64.RawURLEncoding.EncodeToString(rawSig), table: "orders", order: "`created_at` DESC,`id` DESC", n: 2},
+		{name: "no signature", token: payload, table: "orders", order: "`created_at` DESC,`id` DESC", n: 2},
+		{name: "not base64", token: "!." + sig, table: "orders", order: "`created_at` DESC,`id` DESC", n: 2},
+		{name: "other table", token: token, table: "users", order: "`created_at` DESC,`id` DESC", n: 2},
+		{name: "other order", token: token, table: "orders", order: "`created_at`,`id`", n: 2},
+		{name: "other column count", token: token, table: "orders", order: "`created_at` DESC,`id` DESC", n: 1},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, e := decodeCursor(c.token, c.table, c.order, c.n)
+			if c.ok && e != nil {
+				t.Fatal(e)
+			}
+			if !c.ok && !errors.Is(e, ErrInvalidCursor) {
+				t.Fatalf("got %v, want %v", e, ErrInvalidCursor)
+			}
+		})
+	}
+
+	SetCursorKey([]byte("other key"))
+	if _, e := decodeCursor(token, "orders", "`created_at` DESC,`id` DESC", 2); !errors.Is(e, ErrInvalidCursor) {
+		t.Fatalf("signed by another key: got %v, want %v", e, ErrInvalidCursor)
+	}
+}
+
+func TestCursorValue(t *testing.T) {
+	now := time.Date(2026, 10, 19, 8, 30, 0, 123456789, time.UTC)
+	cases := []struct {
+		value any
+		want  any // value returned by argValue
+	}{
+		{value: int64(-42), want: int64(-42)},
+		{value: int32(7), want: int64(7)},
+		{value: uint64(1 << 63), want: uint64(1 << 63)},
+		{value: 1.5, want: 1.5},
+		{value: true, want: true},
+		{value: "a.b", want: "a.b"},
+		{value: []byte{0, 1, 255}, want: []byte{0, 1, 255}},
+		{value: now, want: now},
+	}
+	for _, c := range cases {
+		v, e := cursorValue(c.value)
+		if e != nil {
+			t.Fatalf("%T: %v", c.value, e)
+		}
+		got, e := argValue(v)
+		if e != nil {
+			t.Fatalf("%T: %v", c.value, e)
+		}
+		switch want := c.want.(type) {
+		case []byte:
+			if !bytes.Equal(got.([]byte), want) {
+				t.Errorf("%T: got %v, want %v", c.value, got, want)
+			}
+		case time.Time:
+			if !got.(time.Time).Equal(want) {
+				t.Errorf("%T: got %v, want %v", c.value, got, want)
+			}
+		default:
+			if got != want {
+				t.Errorf("%T: got %#v, want %#v", c.value, got, want)
+			}
+		}
+	}
+
+	if _, e := cursorValue(struct{}{}); e == nil {
+		t.Error("struct value accepted")
+	}
+	for _, v := range [][2]string{{"x", "1"}, {"i", "abc"}, {"t", "yesterday"}} {
+		if _, e := argValue(v); !errors.Is(e, ErrInvalidCursor) {
+			t.Errorf("%v: got %v, want %v", v, e, ErrInvalidCursor)
+		}
+	}
+}
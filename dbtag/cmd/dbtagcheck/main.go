@@ -0,0 +1,11 @@
+// Command dbtagcheck reports malformed db tags, see package dbtag.
+package main
+
+import (
+	"github.com/acsl-go/mysql/dbtag"
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() {
+	singlechecker.Main(dbtag.Analyzer)
+}
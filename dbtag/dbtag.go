@@ -0,0 +1,162 @@
+// Package dbtag provides an analyzer which reports malformed db tags of the structs mapped by github.com/acsl-go/mysql.
+//
+// It is a separate module, so the importers of github.com/acsl-go/mysql do not depend on golang.org/x/tools.
+// It requires a tagged release of github.com/acsl-go/mysql, the go.work next to go.mod uses the local copy instead.
+// It could be run standalone or by go vet:
+//
+//	go install github.com/acsl-go/mysql/dbtag/cmd/dbtagcheck@latest
+//	go vet -vettool=$(which dbtagcheck) ./...
+package dbtag
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/acsl-go/mysql"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+const mysqlPkgPath = "github.com/acsl-go/mysql"
+
+var Analyzer = &analysis.Analyzer{
+	Name:     "dbtag",
+	Doc:      "check the db struct tags used by github.com/acsl-go/mysql",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+var anyPackage bool
+
+func init() {
+	Analyzer.Flags.BoolVar(&anyPackage, "anypkg", false, "check the packages which do not import "+mysqlPkgPath+" as well")
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	if !anyPackage && !importsMySQL(pass.Pkg) {
+		return nil, nil // db tags of other libraries
+	}
+	ins := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	ins.Preorder([]ast.Node{(*ast.StructType)(nil)}, func(n ast.Node) {
+		for _, field := range n.(*ast.StructType).Fields.List {
+			if field.Tag != nil {
+				checkField(pass, field)
+			}
+		}
+	})
+	return nil, nil
+}
+
+func importsMySQL(pkg *types.Package) bool {
+	if pkg.Path() == mysqlPkgPath {
+		return true
+	}
+	for _, imp := range pkg.Imports() {
+		if imp.Path() == mysqlPkgPath {
+			return true
+		}
+	}
+	return false
+}
+
+func checkField(pass *analysis.Pass, field *ast.Field) {
+	lit, e := strconv.Unquote(field.Tag.Value)
+	if e != nil {
+		return
+	}
+	tag, ok := reflect.StructTag(lit).Lookup("db")
+	if !ok || tag == "-" {
+		return
+	}
+
+	var problems []*mysql.TagError
+	if len(field.Names) == 1 && field.Names[0].Name == "_" {
+		problems = mysql.ValidateTableTag(tag)
+	} else {
+		problems = mysql.ValidateTag(tag, kindOf(pass.TypesInfo.TypeOf(field.Type)))
+	}
+	for _, p := range problems {
+		pass.Reportf(tagPos(field.Tag, tag, p.Pos), "db tag: %s", p.Msg)
+	}
+}
+
+// tagPos returns the position of the offset in the db tag, or of the tag literal if it could not be located
+func tagPos(lit *ast.BasicLit, tag string, offset int) token.Pos {
+	if !strings.HasPrefix(lit.Value, "`") {
+		return lit.Pos()
+	}
+	i := strings.Index(lit.Value, `db:"`+tag+`"`)
+	if i < 0 {
+		return lit.Pos() // escaped characters in the tag
+	}
+	return lit.Pos() + token.Pos(i+len(`db:"`)+offset)
+}
+
+// kindOf maps the type of a field to the reflect.Kind passed to mysql.ValidateTag, pointers are dereferenced
+func kindOf(t types.Type) reflect.Kind {
+	if t == nil {
+		return reflect.Invalid
+	}
+	if p, ok := t.Underlying().(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		switch u.Kind() {
+		case types.Bool:
+			return reflect.Bool
+		case types.Int:
+			return reflect.Int
+		case types.Int8:
+			return reflect.Int8
+		case types.Int16:
+			return reflect.Int16
+		case types.Int32:
+			return reflect.Int32
+		case types.Int64:
+			return reflect.Int64
+		case types.Uint:
+			return reflect.Uint
+		case types.Uint8:
+			return reflect.Uint8
+		case types.Uint16:
+			return reflect.Uint16
+		case types.Uint32:
+			return reflect.Uint32
+		case types.Uint64:
+			return reflect.Uint64
+		case types.Uintptr:
+			return reflect.Uintptr
+		case types.Float32:
+			return reflect.Float32
+		case types.Float64:
+			return reflect.Float64
+		case types.Complex64:
+			return reflect.Complex64
+		case types.Complex128:
+			return reflect.Complex128
+		case types.String:
+			return reflect.String
+		}
+	case *types.Struct:
+		return reflect.Struct
+	case *types.Slice:
+		return reflect.Slice
+	case *types.Array:
+		return reflect.Array
+	case *types.Map:
+		return reflect.Map
+	case *types.Interface:
+		return reflect.Interface
+	case *types.Chan:
+		return reflect.Chan
+	case *types.Signature:
+		return reflect.Func
+	}
+	return reflect.Invalid
+}
@@ -0,0 +1,43 @@
+package dbtag
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+// TestAnalyzer checks the diagnostics against the `// want` comments of testdata,
+// package b does not import github.com/acsl-go/mysql and should not be reported
+func TestAnalyzer(t *testing.T) {
+	results := analysistest.Run(t, analysistest.TestData(), Analyzer, "a", "b")
+
+	// The diagnostics point at the offending option in the tag
+	at := map[string]string{
+		"db tag: unknown table option bogus":                            "bogus",
+		"db tag: primary key could not be nullable":                     "null",
+		"db tag: auto increment requires an integer field, got float64": "ai",
+		"db tag: unknown option unknown":                                "unknown",
+		"db tag: blind requires encrypt":                                "blind",
+		"db tag: unclosed bracket":                                      "(1",
+	}
+	for _, result := range results {
+		for _, d := range result.Diagnostics {
+			want, ok := at[d.Message]
+			if !ok {
+				t.Errorf("unexpected diagnostic %q", d.Message)
+				continue
+			}
+			pos := result.Pass.Fset.Position(d.Pos)
+			src, e := os.ReadFile(pos.Filename)
+			if e != nil {
+				t.Fatal(e)
+			}
+			if !strings.HasPrefix(string(src[pos.Offset:]), want) {
+				line := strings.SplitN(string(src[pos.Offset-pos.Column+1:]), "\n", 2)[0]
+				t.Errorf("%s: %q reported at column %d of %q, want at %q", pos, d.Message, pos.Column, line, want)
+			}
+		}
+	}
+}
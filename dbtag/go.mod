@@ -0,0 +1,20 @@
+module github.com/acsl-go/mysql/dbtag
+
+go 1.23.0
+
+require (
+	github.com/acsl-go/mysql v0.1.0
+	golang.org/x/tools v0.34.0
+)
+
+require (
+	filippo.io/edwards25519 v1.1.0 // indirect
+	github.com/acsl-go/logger v0.0.3 // indirect
+	github.com/elastic/elastic-transport-go/v8 v8.3.0 // indirect
+	github.com/elastic/go-elasticsearch/v8 v8.8.2 // indirect
+	github.com/go-sql-driver/mysql v1.8.1 // indirect
+	github.com/pkg/errors v0.9.1 // indirect
+	golang.org/x/mod v0.25.0 // indirect
+	golang.org/x/sync v0.15.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
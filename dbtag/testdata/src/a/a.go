@@ -0,0 +1,17 @@
+package a
+
+import "github.com/acsl-go/mysql"
+
+var _ *mysql.DB
+
+type User struct {
+	_       struct{} `db:"engine(InnoDB) bogus"` // want `db tag: unknown table option bogus`
+	ID      int64    `db:"id pk ai"`
+	Name    string   `db:"name varchar(32) pk null"` // want `db tag: primary key could not be nullable`
+	Score   float64  `db:"score ai"`                 // want `db tag: auto increment requires an integer field, got float64`
+	Note    string   `db:"note unknown"`             // want `db tag: unknown option unknown`
+	Secret  string   `db:"secret blind"`             // want `db tag: blind requires encrypt`
+	Broken  string   `db:"broken def(1"`             // want `db tag: unclosed bracket`
+	Ignored string   `db:"-"`
+	Plain   string   `json:"plain"`
+}
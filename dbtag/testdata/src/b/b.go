@@ -0,0 +1,6 @@
+// Package b does not import github.com/acsl-go/mysql, its db tags belong to another library
+package b
+
+type Row struct {
+	Name string `db:"name unknown"`
+}
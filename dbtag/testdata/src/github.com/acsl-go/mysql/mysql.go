@@ -0,0 +1,4 @@
+// Package mysql stands in for github.com/acsl-go/mysql, the analyzer only checks the packages importing it.
+package mysql
+
+type DB struct{}
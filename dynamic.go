@@ -0,0 +1,626 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// Row is a record of a DynamicSchema, keyed by column name
+type Row map[string]any
+
+// DynamicSchema is a table defined at runtime without a Go struct, the rows are maps of column values.
+// The values are converted and validated against the field definitions before written:
+//
+//	integer types		- signed / unsigned integers, integral floats, numeric strings, json.Number
+//	float, double		- numbers, numeric strings, json.Number
+//	decimal				- numbers and numeric strings, kept as string to keep the precision
+//	char, varchar		- strings and []byte, the length is checked
+//	text, blob types	- strings and []byte
+//	date, datetime		- time.Time, strings in `2006-01-02 15:04:05`, `2006-01-02` or RFC3339 format
+//	time				- time.Time and strings in `15:04:05` format
+//	serialized fields	- any value supported by the serializer
+//
+// Selected values are returned as int64, uint64, float64, string, []byte, time.Time or the deserialized value.
+type DynamicSchema struct {
+	sc *Schema[Row]
+}
+
+// NewDynamicSchema creates or migrates the table with the given fields and indices.
+// If indices is nil, they are generated from Field.Indices and Field.IsPrimaryKey, as the db tags do.
+// opts is optional.
+func NewDynamicSchema(ctx context.Context, dbr *DB, dbw *DB, name string, fields []*Field, indices []*Index, opts *TableOptions) (*DynamicSchema, error) {
+	fields = copyFields(fields) // the fields are completed below, the caller's ones are kept as they are
+	sc := &Schema[Row]{
+		Name:    name,
+		Engine:  "InnoDB",
+		Collate: "utf8mb4_general_ci",
+		Fields:  fields,
+		dbRead:  dbr,
+		dbWrite: dbw,
+	}
+	if opts != nil {
+		sc.applyTableOptions(opts)
+	}
+	for _, field := range fields {
+		if field.Name == "" || field.Type == "" {
+			return nil, errors.New("Name and Type are required for the fields of dynamic schema " + name)
+		}
+		if field.SerializeMethod == CUSTOM && field.Serializer == nil {
+			return nil, errors.New("Serializer is required for the custom serialized field " + field.Name)
+		}
+		if field.SerializeMethod != CUSTOM && field.Serializer != nil {
+			return nil, errors.New("Serializer is set without the CUSTOM serialize method for the field " + field.Name)
+		}
+		if field.Encrypted || field.BlindIndex != "" {
+			return nil, errors.New("Encrypted fields are not supported by dynamic schema: " + field.Name)
+		}
+		if field.IsPrimaryKey && !hasPrimaryKeyDecl(field) {
+			field.Indices = append(field.Indices, &FieldIndexDecl{IndexType: PRIMARY_KEY, IndexName: "PRIMARY"})
+		}
+	}
+	if indices == nil {
+		sc.generateIndices()
+	} else {
+		sc.Indices = indices
+	}
+	sc.generateFieldMap()
+	for _, index := range sc.Indices {
+		if !index.Primary {
+			continue
+		}
+		for _, column := range index.Columns {
+			field, ok := sc.FieldsByColumn[column]
+			if !ok {
+				return nil, errors.New("Unknown primary key column: " + column)
+			}
+			field.IsPrimaryKey = true
+			sc.primaryFields = append(sc.primaryFields, field)
+			sc.primaryWhere += "`" + column + "` = ? AND "
+		}
+	}
+	if len(sc.primaryWhere) > 5 {
+		sc.primaryWhere = sc.primaryWhere[:len(sc.primaryWhere)-5]
+	}
+	for _, field := range fields {
+		if field.IsAutoIncrement {
+			sc.aiField = field
+		}
+	}
+	sc.Checks = make([]*Check, 0)
+	for _, field := range fields {
+		if field.Check != "" {
+			sc.Checks = append(sc.Checks, &Check{Name: checkName(sc.Name, "chk_"+field.Name), Expr: field.Check})
+		}
+	}
+
+	if e := sc.updateSchema(ctx); e != nil {
+		return nil, errors.Wrap(e, "UpdateSchema Failed")
+	}
+	return &DynamicSchema{sc: sc}, nil
+}
+
+func copyFields(fields []*Field) []*Field {
+	copied := make([]*Field, len(fields))
+	for i, field := range fields {
+		fd := *field
+		fd.Indices = append([]*FieldIndexDecl(nil), field.Indices...)
+		copied[i] = &fd
+	}
+	return copied
+}
+
+func hasPrimaryKeyDecl(field *Field) bool {
+	for _, decl := range field.Indices {
+		if decl.IndexType == PRIMARY_KEY {
+			return true
+		}
+	}
+	return false
+}
+
+func (ds *DynamicSchema) Name() string {
+	return ds.sc.Name
+}
+
+func (ds *DynamicSchema) Fields() []*Field {
+	return ds.sc.Fields
+}
+
+func (ds *DynamicSchema) Indices() []*Index {
+	return ds.sc.Indices
+}
+
+func (ds *DynamicSchema) Field(name string) *Field {
+	return ds.sc.FieldsByColumn[name]
+}
+
+// InsertEx inserts a row, the value of the auto increment column is set into the row.
+// Columns missing in the row are left to their defaults, NOT NULL columns without default are required.
+func (ds *DynamicSchema) InsertEx(ctx context.Context, db IDBLike, row Row) error {
+	for column := range row {
+		if _, ok := ds.sc.FieldsByColumn[column]; !ok {
+			return errors.Wrap(ErrInvalidData, "unknown column: "+column)
+		}
+	}
+	columns := ""
+	marks := ""
+	args := make([]any, 0, len(row))
+	for _, field := range ds.sc.Fields {
+		value, ok := row[field.Name]
+		if !ok {
+			if !field.IsNullable && !field.IsAutoIncrement && !field.IsGenerated() && field.DefaultValue == "" {
+				return errors.Wrap(ErrInvalidData, "column is required: "+field.Name)
+			}
+			continue
+		}
+		if field.IsGenerated() {
+			return errors.Wrap(ErrInvalidData, "generated column could not be written: "+field.Name)
+		}
+		v, e := convertDynamicValue(field, value)
+		if e != nil {
+			return e
+		}
+		columns += "`" + field.Name + "`,"
+		marks += "?,"
+		args = append(args, v)
+	}
+	if columns == "" {
+		return errors.Wrap(ErrInvalidData, "empty row")
+	}
+
+	s := "INSERT INTO `" + ds.sc.Name + "` (" + columns[:len(columns)-1] + ") VALUES (" + marks[:len(marks)-1] + ")"
+	r, e := db.ExecContext(ctx, s, args...)
+	if e != nil {
+		return wrapExecError(e, "Insert failed")
+	}
+	if ds.sc.aiField != nil {
+		if _, ok := row[ds.sc.aiField.Name]; !ok {
+			id, e := r.LastInsertId()
+			if e != nil {
+				return errors.Wrap(e, "Get last insert id failed")
+			}
+			row[ds.sc.aiField.Name] = id
+		}
+	}
+	return nil
+}
+
+func (ds *DynamicSchema) Insert(ctx context.Context, row Row) error {
+	return ds.InsertEx(ctx, ds.sc.dbWrite.Ctx, row)
+}
+
+// UpdateEx updates a row by its primary key, all the other columns in the row are updated if columns is empty.
+func (ds *DynamicSchema) UpdateEx(ctx context.Context, db IDBLike, row Row, columns ...string) (int64, error) {
+	if len(ds.sc.primaryFields) == 0 {
+		return 0, ErrNoPrimaryKey
+	}
+	if len(columns) == 0 {
+		for _, field := range ds.sc.Fields {
+			if _, ok := row[field.Name]; ok && !field.IsPrimaryKey {
+				columns = append(columns, field.Name)
+			}
+		}
+	}
+	if len(columns) == 0 {
+		return 0, errors.Wrap(ErrInvalidData, "nothing to update")
+	}
+
+	s := "UPDATE `" + ds.sc.Name + "` SET "
+	args := make([]any, 0, len(columns)+len(ds.sc.primaryFields))
+	for _, column := range columns {
+		field, ok := ds.sc.FieldsByColumn[column]
+		if !ok {
+			return 0, errors.New("Unknown column: " + column)
+		}
+		if field.IsPrimaryKey {
+			return 0, errors.New("Cannot update primary key: " + column)
+		}
+		if field.IsGenerated() {
+			return 0, errors.New("Cannot update generated column: " + column)
+		}
+		v, e := convertDynamicValue(field, row[column])
+		if e != nil {
+			return 0, e
+		}
+		s += "`" + column + "` = ?,"
+		args = append(args, v)
+	}
+	s = s[:len(s)-1] + " WHERE " + ds.sc.primaryWhere
+	for _, field := range ds.sc.primaryFields {
+		value, ok := row[field.Name]
+		if !ok {
+			return 0, errors.Wrap(ErrNoPrimaryKey, "missing "+field.Name)
+		}
+		v, e := convertDynamicValue(field, value)
+		if e != nil {
+			return 0, e
+		}
+		args = append(args, v)
+	}
+
+	r, e := db.ExecContext(ctx, s, args...)
+	if e != nil {
+		return 0, wrapExecError(e, "Update failed")
+	}
+	n, e := r.RowsAffected()
+	if e != nil {
+		return 0, errors.Wrap(e, "Get rows affected failed")
+	}
+	return n, nil
+}
+
+func (ds *DynamicSchema) Update(ctx context.Context, row Row, columns ...string) (int64, error) {
+	return ds.UpdateEx(ctx, ds.sc.dbWrite.Ctx, row, columns...)
+}
+
+func (ds *DynamicSchema) SelectEx(ctx context.Context, db IDBLike, where any, args ...any) ([]Row, error) {
+	cond, tail, args, e := buildWhere(where, args, ds.sc.FieldsByColumn, nil)
+	if e != nil {
+		return nil, e
+	}
+	s := "SELECT " + ds.columnNames() + " FROM `" + ds.sc.Name + "`" + cond + tail
+	rows, e := db.QueryContext(ctx, s, args...)
+	if e != nil {
+		return nil, errors.Wrap(e, "Select failed")
+	}
+	defer rows.Close()
+	result := make([]Row, 0)
+	for rows.Next() {
+		row, e := ds.scan(rows)
+		if e != nil {
+			return nil, e
+		}
+		result = append(result, row)
+	}
+	if e := rows.Err(); e != nil {
+		return nil, errors.Wrap(e, "Select failed")
+	}
+	return result, nil
+}
+
+func (ds *DynamicSchema) Select(ctx context.Context, where any, args ...any) ([]Row, error) {
+	return ds.SelectEx(ctx, ds.sc.dbRead.Ctx, where, args...)
+}
+
+func (ds *DynamicSchema) SelectOneEx(ctx context.Context, db IDBLike, where any, args ...any) (Row, error) {
+	cond, tail, args, e := buildWhere(where, args, ds.sc.FieldsByColumn, nil)
+	if e != nil {
+		return nil, e
+	}
+	s := "SELECT " + ds.columnNames() + " FROM `" + ds.sc.Name + "`" + cond + tail
+	row, e := ds.scan(db.QueryRowContext(ctx, s, args...))
+	if e != nil {
+		if errors.Is(e, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, e
+	}
+	return row, nil
+}
+
+func (ds *DynamicSchema) SelectOne(ctx context.Context, where any, args ...any) (Row, error) {
+	return ds.SelectOneEx(ctx, ds.sc.dbRead.Ctx, where, args...)
+}
+
+func (ds *DynamicSchema) DeleteEx(ctx context.Context, db IDBLike, where any, args ...any) (int64, error) {
+	return ds.sc.DeleteEx(ctx, db, where, args...)
+}
+
+func (ds *DynamicSchema) Delete(ctx context.Context, where any, args ...any) (int64, error) {
+	return ds.sc.Delete(ctx, where, args...)
+}
+
+func (ds *DynamicSchema) CountEx(ctx context.Context, db IDBLike, where any, args ...any) (int64, error) {
+	return ds.sc.CountEx(ctx, db, where, args...)
+}
+
+func (ds *DynamicSchema) Count(ctx context.Context, where any, args ...any) (int64, error) {
+	return ds.sc.Count(ctx, where, args...)
+}
+
+func (ds *DynamicSchema) columnNames() string {
+	s := ""
+	for _, field := range ds.sc.Fields {
+		s += "`" + field.Name + "`,"
+	}
+	return s[:len(s)-1]
+}
+
+func (ds *DynamicSchema) scan(r rowLike) (Row, error) {
+	values := make([]any, len(ds.sc.Fields))
+	dest := make([]any, len(values))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if e := r.Scan(dest...); e != nil {
+		return nil, errors.Wrap(e, "scan failed")
+	}
+	row := make(Row, len(values))
+	for i, field := range ds.sc.Fields {
+		v, e := scannedDynamicValue(field, values[i])
+		if e != nil {
+			return nil, e
+		}
+		row[field.Name] = v
+	}
+	return row, nil
+}
+
+// baseType returns the type name of a column in lower case without length and attributes, e.g. varchar
+func baseType(columnType string) string {
+	t := strings.ToLower(columnType)
+	if i := strings.IndexAny(t, "( "); i >= 0 {
+		t = t[:i]
+	}
+	return t
+}
+
+// typeLength returns the length of the column type, e.g. 64 for varchar(64), or 0 if not specified
+func typeLength(columnType string) int {
+	i := strings.IndexByte(columnType, '(')
+	j := strings.IndexAny(columnType, ",)")
+	if i < 0 || j < i {
+		return 0
+	}
+	n, _ := strconv.Atoi(strings.TrimSpace(columnType[i+1 : j]))
+	return n
+}
+
+func isUnsignedType(columnType string) bool {
+	return strings.Contains(strings.ToLower(columnType), "unsigned")
+}
+
+// decimalLiteral matches the numbers accepted by a decimal column, unlike strconv.ParseFloat it rejects NaN and Inf
+var decimalLiteral = regexp.MustCompile(`^[+-]?([0-9]+(\.[0-9]*)?|\.[0-9]+)([eE][+-]?[0-9]+)?$`)
+
+// convertDynamicValue converts and validates a value to be written into the column
+func convertDynamicValue(field *Field, value any) (any, error) {
+	invalid := func(reason string) error {
+		return errors.Wrap(ErrInvalidData, fmt.Sprintf("column %s: %s: %v", field.Name, reason, value))
+	}
+	if value == nil {
+		if !field.IsNullable {
+			return nil, invalid("could not be null")
+		}
+		return nil, nil
+	}
+	if ser := field.serializer(); ser != nil {
+		b, e := ser.Marshal(value)
+		if e != nil {
+			return nil, errors.Wrap(e, "Serialize "+field.Name+" failed")
+		}
+		if ser.Binary() {
+			return b, nil
+		}
+		return string(b), nil
+	}
+
+	switch t := baseType(field.Type); t {
+	case "tinyint", "smallint", "mediumint", "int", "integer", "bigint", "bit", "year":
+		if b, ok := value.(bool); ok && t == "tinyint" {
+			if b {
+				return 1, nil
+			}
+			return 0, nil
+		}
+		if isUnsignedType(field.Type) {
+			n, ok := toUint64(value)
+			if !ok {
+				return nil, invalid("not an unsigned integer")
+			}
+			return n, nil
+		}
+		n, ok := toInt64(value)
+		if !ok {
+			return nil, invalid("not an integer")
+		}
+		return n, nil
+	case "float", "double", "real":
+		n, ok := toFloat64(value)
+		if !ok || math.IsNaN(n) || math.IsInf(n, 0) {
+			return nil, invalid("not a number")
+		}
+		return n, nil
+	case "decimal", "numeric":
+		switch v := value.(type) {
+		case string:
+			if !decimalLiteral.MatchString(v) {
+				return nil, invalid("not a number")
+			}
+			return v, nil
+		case json.Number:
+			if !decimalLiteral.MatchString(v.String()) {
+				return nil, invalid("not a number")
+			}
+			return v.String(), nil
+		}
+		n, ok := toFloat64(value)
+		if !ok || math.IsNaN(n) || math.IsInf(n, 0) {
+			return nil, invalid("not a number")
+		}
+		return strconv.FormatFloat(n, 'f', -1, 64), nil
+	case "char", "varchar", "binary", "varbinary":
+		var s string
+		switch v := value.(type) {
+		case string:
+			s = v
+		case []byte:
+			s = string(v)
+		default:
+			return nil, invalid("not a string")
+		}
+		length := utf8.RuneCountInString(s)
+		if t == "binary" || t == "varbinary" {
+			length = len(s)
+		}
+		if n := typeLength(field.Type); n > 0 && length > n {
+			return nil, invalid("longer than " + strconv.Itoa(n))
+		}
+		return value, nil
+	case "tinytext", "text", "mediumtext", "longtext", "tinyblob", "blob", "mediumblob", "longblob", "json", "enum", "set":
+		switch value.(type) {
+		case string, []byte:
+			return value, nil
+		}
+		return nil, invalid("not a string")
+	case "date", "datetime", "timestamp", "time":
+		switch v := value.(type) {
+		case time.Time:
+			return v, nil
+		case string:
+			for _, layout := range []string{"2006-01-02 15:04:05", time.RFC3339Nano, "2006-01-02", "15:04:05"} {
+				if layout == "15:04:05" && t != "time" {
+					continue // a time of day is not a date
+				}
+				if tm, e := time.ParseInLocation(layout, v, time.Local); e == nil {
+					if t == "time" {
+						return v, nil
+					}
+					return tm, nil
+				}
+			}
+		}
+		return nil, invalid("not a time")
+	}
+	return value, nil
+}
+
+// scannedDynamicValue converts a value scanned from the driver into the value returned in Row
+func scannedDynamicValue(field *Field, value any) (any, error) {
+	if value == nil {
+		return nil, nil
+	}
+	if ser := field.serializer(); ser != nil {
+		var b []byte
+		switch v := value.(type) {
+		case []byte:
+			b = v
+		case string:
+			b = []byte(v)
+		}
+		if len(b) == 0 {
+			return nil, nil
+		}
+		var r any
+		if e := ser.Unmarshal(b, &r); e != nil {
+			return nil, errors.Wrap(e, "Deserialize "+field.Name+" failed")
+		}
+		return r, nil
+	}
+	b, isBytes := value.([]byte)
+	switch baseType(field.Type) {
+	case "tinyint", "smallint", "mediumint", "int", "integer", "bigint", "year":
+		if isBytes {
+			if isUnsignedType(field.Type) {
+				return strconv.ParseUint(string(b), 10, 64)
+			}
+			return strconv.ParseInt(string(b), 10, 64)
+		}
+	case "float", "double", "real":
+		if isBytes {
+			return strconv.ParseFloat(string(b), 64)
+		}
+	case "tinyblob", "blob", "mediumblob", "longblob", "binary", "varbinary", "bit":
+		if isBytes {
+			return append([]byte{}, b...), nil
+		}
+	default:
+		if isBytes {
+			return string(b), nil
+		}
+	}
+	return value, nil
+}
+
+func toInt64(value any) (int64, bool) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), true
+	case int8:
+		return int64(v), true
+	case int16:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case uint:
+		return int64(v), v <= math.MaxInt64
+	case uint8:
+		return int64(v), true
+	case uint16:
+		return int64(v), true
+	case uint32:
+		return int64(v), true
+	case uint64:
+		return int64(v), v <= math.MaxInt64
+	case float32:
+		return int64(v), float32(int64(v)) == v
+	case float64:
+		return int64(v), float64(int64(v)) == v
+	case string:
+		n, e := strconv.ParseInt(v, 10, 64)
+		return n, e == nil
+	case json.Number:
+		n, e := v.Int64()
+		return n, e == nil
+	}
+	return 0, false
+}
+
+func toUint64(value any) (uint64, bool) {
+	switch v := value.(type) {
+	case uint:
+		return uint64(v), true
+	case uint8:
+		return uint64(v), true
+	case uint16:
+		return uint64(v), true
+	case uint32:
+		return uint64(v), true
+	case uint64:
+		return v, true
+	case string:
+		n, e := strconv.ParseUint(v, 10, 64)
+		return n, e == nil
+	case json.Number:
+		n, e := strconv.ParseUint(v.String(), 10, 64)
+		return n, e == nil
+	}
+	if n, ok := toInt64(value); ok && n >= 0 {
+		return uint64(n), true
+	}
+	return 0, false
+}
+
+func toFloat64(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	case string:
+		n, e := strconv.ParseFloat(v, 64)
+		return n, e == nil
+	case json.Number:
+		n, e := v.Float64()
+		return n, e == nil
+	}
+	if n, ok := toInt64(value); ok {
+		return float64(n), true
+	}
+	if n, ok := toUint64(value); ok {
+		return float64(n), true
+	}
+	return 0, false
+}
@@ -0,0 +1,237 @@
+package mysql
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrNoKeyProvider = errors.New("key provider is not set, call SetKeyProvider first")
+	ErrInvalidCipher = errors.New("invalid cipher data")
+)
+
+// KeyProvider provides the keys of the encrypted columns, keys should be 16, 24 or 32 bytes for AES-128, AES-192 or AES-256.
+// The id of the key is stored with the cipher data, so old keys should be kept until all rows are re-encrypted.
+type KeyProvider interface {
+	// CurrentKey returns the key to encrypt new data
+	CurrentKey() (id string, key []byte, err error)
+	// Key returns the key by id to decrypt data
+	Key(id string) ([]byte, error)
+}
+
+// StaticKeyProvider is a KeyProvider with fixed keys
+type StaticKeyProvider struct {
+	Keys     map[string][]byte
+	Current  string // id of the current key
+	BlindKey []byte // HMAC key of blind indices, optional
+}
+
+func (p *StaticKeyProvider) CurrentKey() (string, []byte, error) {
+	key, e := p.Key(p.Current)
+	return p.Current, key, e
+}
+
+func (p *StaticKeyProvider) Key(id string) ([]byte, error) {
+	if key, ok := p.Keys[id]; ok {
+		return key, nil
+	}
+	return nil, errors.New("key not found: " + id)
+}
+
+var (
+	keyProvider   KeyProvider
+	keyProviderMu sync.RWMutex
+)
+
+// SetKeyProvider sets the key provider of all encrypted columns
+func SetKeyProvider(p KeyProvider) {
+	keyProviderMu.Lock()
+	defer keyProviderMu.Unlock()
+	keyProvider = p
+}
+
+func getKeyProvider() (KeyProvider, error) {
+	keyProviderMu.RLock()
+	defer keyProviderMu.RUnlock()
+	if keyProvider == nil {
+		return nil, ErrNoKeyProvider
+	}
+	return keyProvider, nil
+}
+
+const cipherVersion = 1
+
+// encryptValue encrypts data by AES-GCM with the current key, the column name is used as additional data,
+// the row is not, see Field.Encrypted.
+// Format: version(1) | len(key id)(1) | key id | nonce | sealed data
+func encryptValue(column string, data []byte) ([]byte, error) {
+	p, e := getKeyProvider()
+	if e != nil {
+		return nil, e
+	}
+	id, key, e := p.CurrentKey()
+	if e != nil {
+		return nil, errors.Wrap(e, "Get current key failed")
+	}
+	if len(id) > 255 {
+		return nil, errors.New("key id too long: " + id)
+	}
+	aead, e := newAEAD(key)
+	if e != nil {
+		return nil, e
+	}
+	out := make([]byte, 0, 2+len(id)+aead.NonceSize()+len(data)+aead.Overhead())
+	out = append(out, cipherVersion, byte(len(id)))
+	out = append(out, id...)
+	nonce := make([]byte, aead.NonceSize())
+	if _, e := rand.Read(nonce); e != nil {
+		return nil, errors.Wrap(e, "Generate nonce failed")
+	}
+	out = append(out, nonce...)
+	return aead.Seal(out, nonce, data, []byte(column)), nil
+}
+
+// decryptValue decrypts the data produced by encryptValue
+func decryptValue(column string, data []byte) ([]byte, error) {
+	id, body, e := parseCipher(data)
+	if e != nil {
+		return nil, e
+	}
+	p, e := getKeyProvider()
+	if e != nil {
+		return nil, e
+	}
+	key, e := p.Key(id)
+	if e != nil {
+		return nil, errors.Wrap(e, "Get key failed")
+	}
+	aead, e := newAEAD(key)
+	if e != nil {
+		return nil, e
+	}
+	if len(body) < aead.NonceSize() {
+		return nil, ErrInvalidCipher
+	}
+	plain, e := aead.Open(nil, body[:aead.NonceSize()], body[aead.NonceSize():], []byte(column))
+	if e != nil {
+		return nil, errors.Wrap(ErrInvalidCipher, e.Error())
+	}
+	return plain, nil
+}
+
+// parseCipher returns the key id and the remaining part of the cipher data
+func parseCipher(data []byte) (string, []byte, error) {
+	if len(data) < 2 || data[0] != cipherVersion || len(data) < 2+int(data[1]) {
+		return "", nil, ErrInvalidCipher
+	}
+	n := 2 + int(data[1])
+	return string(data[2:n]), data[n:], nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, e := aes.NewCipher(key)
+	if e != nil {
+		return nil, errors.Wrap(e, "Create cipher failed")
+	}
+	return cipher.NewGCM(block)
+}
+
+// fieldPlainBytes converts the value of an encrypted field into the bytes to be encrypted,
+// by the encoder of its registered type, its serializer, or as a string or []byte
+func fieldPlainBytes(field *Field, v reflect.Value) ([]byte, error) {
+	if ct := field.columnType; ct != nil {
+		if v.Kind() == reflect.Ptr && v.Type() != ct.goType {
+			v = v.Elem() // registered as the element type of a pointer field
+		}
+		dv := v.Interface()
+		var e error
+		if ct.Encode != nil {
+			dv, e = ct.Encode(dv)
+		} else if valuer, ok := dv.(driver.Valuer); ok {
+			dv, e = valuer.Value()
+		}
+		if e != nil {
+			return nil, e
+		}
+		return driverValueBytes(dv)
+	}
+	if ser := field.serializer(); ser != nil {
+		return ser.Marshal(v.Interface())
+	}
+	return plainBytes(v.Interface())
+}
+
+// driverValueBytes converts a value passed to the driver into bytes in the text format of MySQL,
+// so it could be decoded as a value scanned from the driver
+func driverValueBytes(v interface{}) ([]byte, error) {
+	switch d := v.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		return d, nil
+	case string:
+		return []byte(d), nil
+	case int64:
+		return []byte(strconv.FormatInt(d, 10)), nil
+	case float64:
+		return []byte(strconv.FormatFloat(d, 'g', -1, 64)), nil
+	case bool:
+		if d {
+			return []byte("1"), nil
+		}
+		return []byte("0"), nil
+	case time.Time:
+		return []byte(d.Format("2006-01-02 15:04:05.999999")), nil
+	}
+	return plainBytes(v)
+}
+
+// plainBytes converts the value to be encrypted into bytes
+func plainBytes(v interface{}) ([]byte, error) {
+	switch d := v.(type) {
+	case []byte:
+		return d, nil
+	case string:
+		return []byte(d), nil
+	}
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() == reflect.String {
+		return []byte(rv.String()), nil
+	}
+	if rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() == reflect.Uint8 {
+		return rv.Bytes(), nil
+	}
+	return nil, errors.Errorf("unsupported type to encrypt: %T", v)
+}
+
+// setPlainBytes sets the decrypted bytes into a string or []byte field, or a pointer to them
+func setPlainBytes(fv reflect.Value, plain []byte) error {
+	if fv.Kind() == reflect.Ptr {
+		fv.Set(reflect.New(fv.Type().Elem()))
+		fv = fv.Elem()
+	}
+	if scanner, ok := fv.Addr().Interface().(sql.Scanner); ok {
+		return scanner.Scan(plain)
+	}
+	switch {
+	case fv.Kind() == reflect.String:
+		fv.SetString(string(plain))
+	case fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() == reflect.Uint8:
+		fv.SetBytes(plain)
+	default:
+		return errors.New("unsupported type to decrypt: " + fv.Type().String())
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package mysql
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestEncryptValue(t *testing.T) {
+	key16 := bytes.Repeat([]byte{1}, 16)
+	key32 := bytes.Repeat([]byte{2}, 32)
+	p := &StaticKeyProvider{Keys: map[string][]byte{"k16": key16, "k32": key32}}
+	SetKeyProvider(p)
+	defer SetKeyProvider(nil)
+
+	cases := []struct {
+		name    string
+		current string // key encrypting the data
+		column  string // column decrypting the data
+		keys    map[string][]byte
+		plain   []byte
+		wantErr error
+	}{
+		{name: "aes-128", current: "k16", column: "secret", plain: []byte("hello")},
+		{name: "aes-256", current: "k32", column: "secret", plain: []byte("hello")},
+		{name: "empty", current: "k32", column: "secret", plain: []byte{}},
+		{name: "rotated key", current: "k16", column: "secret", keys: map[string][]byte{"k16": key16, "k32": key32}, plain: []byte("old data")},
+		{name: "wrong key", current: "k16", column: "secret", keys: map[string][]byte{"k16": key32}, plain: []byte("hello"), wantErr: ErrInvalidCipher},
+		{name: "wrong column", current: "k16", column: "other", plain: []byte("hello"), wantErr: ErrInvalidCipher},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			p.Keys = map[string][]byte{"k16": key16, "k32": key32}
+			p.Current = c.current
+			sealed, e := encryptValue("secret", c.plain)
+			if e != nil {
+				t.Fatal(e)
+			}
+			if len(c.plain) > 0 && bytes.Contains(sealed, c.plain) {
+				t.Fatalf("plain text found in %x", sealed)
+			}
+			if c.keys != nil {
+				p.Keys = c.keys
+				p.Current = "k32"
+			}
+			plain, e := decryptValue(c.column, sealed)
+			if c.wantErr != nil {
+				if !errors.Is(e, c.wantErr) {
+					t.Fatalf("got %v, want %v", e, c.wantErr)
+				}
+				return
+			}
+			if e != nil {
+				t.Fatal(e)
+			}
+			if !bytes.Equal(plain, c.plain) {
+				t.Fatalf("got %q, want %q", plain, c.plain)
+			}
+		})
+	}
+}
+
+func TestDecryptInvalidCipher(t *testing.T) {
+	SetKeyProvider(&StaticKeyProvider{Keys: map[string][]byte{"k": bytes.Repeat([]byte{1}, 32)}, Current: "k"})
+	defer SetKeyProvider(nil)
+	sealed, e := encryptValue("secret", []byte("hello"))
+	if e != nil {
+		t.Fatal(e)
+	}
+	tampered := append([]byte(nil), sealed...)
+	tampered[len(tampered)-1] ^= 1
+
+	cases := []struct {
+		name string
+		data []byte
+	}{
+		{name: "empty", data: nil},
+		{name: "unknown version", data: append([]byte{9}, sealed[1:]...)},
+		{name: "truncated key id", data: sealed[:2]},
+		{name: "truncated nonce", data: sealed[:5]},
+		{name: "tampered", data: tampered},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if _, e := decryptValue("secret", c.data); !errors.Is(e, ErrInvalidCipher) {
+				t.Fatalf("got %v, want %v", e, ErrInvalidCipher)
+			}
+		})
+	}
+}
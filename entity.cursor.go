@@ -0,0 +1,262 @@
+package mysql
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// SelectCursorEx selects a page of records by keyset pagination, which is as fast on deep pages as on the first one
+// and stable while rows are inserted, but there is no total count.
+// The order is taken from where if it is a *Query, the primary key is appended to make it unique,
+// so it is the primary key by default. The order columns should be selected by the entity, not nullable,
+// not encrypted and not serialized. An empty cursor selects the first page.
+// Return: records, cursor of the next page, cursor of the previous page, error.
+// The cursors are empty if there is no next or previous page, they are signed and could be passed to clients.
+func (ent *Entity[T]) SelectCursorEx(ctx context.Context, db IDBLike, cursor string, size int64, where any, args ...any) ([]*T, string, string, error) {
+	if size < 1 {
+		size = 1
+	}
+	orders, where, e := ent.cursorOrder(where)
+	if e != nil {
+		return nil, "", "", e
+	}
+	keyFields, e := ent.cursorFields(orders)
+	if e != nil {
+		return nil, "", "", e
+	}
+	spec := orderSpec(orders, false)
+
+	backward := false
+	if cursor != "" {
+		c, e := decodeCursor(cursor, ent.tableNameStr, spec, len(orders))
+		if e != nil {
+			return nil, "", "", e
+		}
+		values := make([]any, len(c.Values))
+		for i, v := range c.Values {
+			if values[i], e = argValue(v); e != nil {
+				return nil, "", "", e
+			}
+		}
+		backward = c.Backward
+		if where, e = andWhere(where, args, keysetCond(orders, values, backward)); e != nil {
+			return nil, "", "", e
+		}
+		args = nil
+	}
+
+	cond, _, args, e := buildWhere(where, args, ent.fieldsByColumn, ent.aliases)
+	if e != nil {
+		return nil, "", "", e
+	}
+	vargs := make([]any, 0, len(args)+1)
+	vargs = append(vargs, args...)
+	vargs = append(vargs, size+1) // one more row to know if there is another page
+	sql := "SELECT " + ent.columnNamesStr + " FROM `" + ent.tableNameStr + "`" + cond + " ORDER BY " + orderSpec(orders, backward) + " LIMIT ?"
+	rows, e := db.QueryContext(ctx, sql, vargs...)
+	if e != nil {
+		return nil, "", "", errors.Wrap(e, "SelectCursor failed")
+	}
+	defer rows.Close()
+	result := make([]*T, 0, size)
+	for rows.Next() {
+		v := new(T)
+		if e := ent.scan(rows, v); e != nil {
+			return nil, "", "", errors.Wrap(e, "SelectCursor failed")
+		}
+		result = append(result, v)
+	}
+	if e := rows.Err(); e != nil {
+		return nil, "", "", errors.Wrap(e, "SelectCursor failed")
+	}
+
+	more := int64(len(result)) > size
+	if more {
+		result = result[:size]
+	}
+	if backward {
+		for i, j := 0, len(result)-1; i < j; i, j = i+1, j-1 {
+			result[i], result[j] = result[j], result[i]
+		}
+	}
+	if len(result) == 0 {
+		return result, "", "", nil
+	}
+
+	next, prev := "", ""
+	if more || backward { // a backward page is always followed by the row of its cursor
+		if next, e = ent.cursorOf(spec, keyFields, result[len(result)-1], false); e != nil {
+			return nil, "", "", e
+		}
+	}
+	if (more && backward) || (!backward && cursor != "") {
+		if prev, e = ent.cursorOf(spec, keyFields, result[0], true); e != nil {
+			return nil, "", "", e
+		}
+	}
+	return result, next, prev, nil
+}
+
+func (ent *Entity[T]) SelectCursor(ctx context.Context, cursor string, size int64, where any, args ...any) ([]*T, string, string, error) {
+	return ent.SelectCursorEx(ctx, ent.dbRead.Ctx, cursor, size, where, args...)
+}
+
+// cursorOrder returns the order of SelectCursor, and the where without the order
+func (ent *Entity[T]) cursorOrder(where any) ([]queryOrder, any, error) {
+	var orders []queryOrder
+	if q, ok := where.(*Query); ok && q != nil {
+		if q.limit >= 0 || q.offset > 0 {
+			return nil, nil, errors.New("LIMIT could not be used with SelectCursor")
+		}
+		if len(q.groups) > 0 {
+			return nil, nil, errors.New("GROUP BY could not be used with SelectCursor")
+		}
+		orders = append(orders, q.orders...)
+		w := *q
+		w.orders = nil
+		where = &w
+	}
+	desc := len(orders) > 0 && orders[0].desc // same direction as the first column for the row comparison
+	for _, column := range ent.primaryColumns {
+		found := false
+		for _, order := range orders {
+			if order.column == column {
+				found = true
+				break
+			}
+		}
+		if !found {
+			orders = append(orders, queryOrder{column: column, desc: desc})
+		}
+	}
+	if len(orders) == 0 {
+		return nil, nil, ErrNoPrimaryKey
+	}
+	return orders, where, nil
+}
+
+// cursorFields returns the fields of the order columns in the entity
+func (ent *Entity[T]) cursorFields(orders []queryOrder) ([]*entityField, error) {
+	fields := make([]*entityField, len(orders))
+	for i, order := range orders {
+		for _, field := range ent.fields {
+			if field.ColumnName == order.column {
+				fields[i] = field
+				break
+			}
+		}
+		if fields[i] == nil {
+			if _, ok := ent.fieldsByColumn[order.column]; !ok {
+				return nil, errors.New("Unknown column: " + order.column)
+			}
+			return nil, errors.New("Order column is not selected by the entity: " + order.column)
+		}
+		fs := fields[i].FieldSchema
+		if fs.Expr != "" {
+			return nil, errors.New("Expression could not be used by SelectCursor: " + order.column)
+		}
+		if fs.IsNullable || fs.Encrypted || fs.serializer() != nil {
+			return nil, errors.New("Nullable, encrypted or serialized column could not be used by SelectCursor: " + order.column)
+		}
+	}
+	return fields, nil
+}
+
+// cursorOf returns the cursor of the rows after (or before) data
+func (ent *Entity[T]) cursorOf(spec string, fields []*entityField, data *T, backward bool) (string, error) {
+	val := reflect.ValueOf(data).Elem()
+	c := &cursorToken{
+		Table:    ent.tableNameStr,
+		Order:    spec,
+		Backward: backward,
+		Values:   make([][2]string, len(fields)),
+	}
+	for i, field := range fields {
+		v := fieldValueByIndex(val, field.FieldIndex)
+		var arg any
+		if ct := field.FieldSchema.columnType; ct != nil && ct.Encode != nil {
+			if v.Kind() == reflect.Ptr && v.Type() != ct.goType {
+				v = v.Elem() // registered as the element type of a pointer field
+			}
+			r, e := ct.Encode(v.Interface())
+			if e != nil {
+				return "", errors.Wrap(e, "Encode "+field.ColumnName+" failed")
+			}
+			arg = r
+		} else {
+			if v.Kind() == reflect.Ptr {
+				v = v.Elem()
+			}
+			arg = v.Interface()
+		}
+		cv, e := cursorValue(arg)
+		if e != nil {
+			return "", e
+		}
+		c.Values[i] = cv
+	}
+	return encodeCursor(c)
+}
+
+// keysetCond returns the condition of the rows after (or before) the values in the order,
+// e.g. (`a`,`b`) > (?,?), or `a` > ? OR (`a` = ? AND `b` < ?) if the directions are mixed.
+func keysetCond(orders []queryOrder, values []any, backward bool) Cond {
+	ops := make([]string, len(orders))
+	mixed := false
+	for i, order := range orders {
+		ops[i] = ">"
+		if order.desc != backward {
+			ops[i] = "<"
+		}
+		mixed = mixed || ops[i] != ops[0]
+	}
+
+	if !mixed {
+		columns, marks := "", ""
+		for i, order := range orders {
+			if i > 0 {
+				columns += ","
+				marks += ","
+			}
+			columns += "`" + order.column + "`"
+			marks += "?"
+		}
+		if len(orders) == 1 {
+			return Raw(columns+" "+ops[0]+" ?", values...)
+		}
+		return Raw("("+columns+") "+ops[0]+" ("+marks+")", values...)
+	}
+
+	sql := ""
+	args := make([]any, 0)
+	for i := range orders {
+		if i > 0 {
+			sql += " OR "
+		}
+		sql += "("
+		for j := 0; j < i; j++ {
+			sql += "`" + orders[j].column + "` = ? AND "
+			args = append(args, values[j])
+		}
+		sql += "`" + orders[i].column + "` " + ops[i] + " ?)"
+		args = append(args, values[i])
+	}
+	return Raw(sql, args...)
+}
+
+// orderSpec returns the ORDER BY list of the orders, reversed if backward
+func orderSpec(orders []queryOrder, backward bool) string {
+	spec := ""
+	for i, order := range orders {
+		if i > 0 {
+			spec += ","
+		}
+		spec += "`" + order.column + "`"
+		if order.desc != backward {
+			spec += " DESC"
+		}
+	}
+	return spec
+}
@@ -0,0 +1,74 @@
+package mysql
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestKeysetCond(t *testing.T) {
+	cases := []struct {
+		name     string
+		orders   []queryOrder
+		backward bool
+		sql      string
+		args     []any
+	}{
+		{
+			name:   "single",
+			orders: []queryOrder{{column: "id"}},
+			sql:    "`id` > ?",
+			args:   []any{1},
+		},
+		{
+			name:     "single backward",
+			orders:   []queryOrder{{column: "id"}},
+			backward: true,
+			sql:      "`id` < ?",
+			args:     []any{1},
+		},
+		{
+			name:   "all desc",
+			orders: []queryOrder{{column: "created_at", desc: true}, {column: "id", desc: true}},
+			sql:    "(`created_at`,`id`) < (?,?)",
+			args:   []any{1, 2},
+		},
+		{
+			name:     "all desc backward",
+			orders:   []queryOrder{{column: "created_at", desc: true}, {column: "id", desc: true}},
+			backward: true,
+			sql:      "(`created_at`,`id`) > (?,?)",
+			args:     []any{1, 2},
+		},
+		{
+			name:   "mixed",
+			orders: []queryOrder{{column: "score", desc: true}, {column: "name"}, {column: "id"}},
+			sql:    "(`score` < ?) OR (`score` = ? AND `name` > ?) OR (`score` = ? AND `name` = ? AND `id` > ?)",
+			args:   []any{1, 1, 2, 1, 2, 3},
+		},
+		{
+			name:     "mixed backward",
+			orders:   []queryOrder{{column: "score", desc: true}, {column: "id"}},
+			backward: true,
+			sql:      "(`score` > ?) OR (`score` = ? AND `id` < ?)",
+			args:     []any{1, 1, 2},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			values := make([]any, len(c.orders))
+			for i := range values {
+				values[i] = i + 1
+			}
+			sql, args, e := keysetCond(c.orders, values, c.backward).build(nil)
+			if e != nil {
+				t.Fatal(e)
+			}
+			if sql != c.sql {
+				t.Errorf("got %s, want %s", sql, c.sql)
+			}
+			if !reflect.DeepEqual(args, c.args) {
+				t.Errorf("got args %v, want %v", args, c.args)
+			}
+		})
+	}
+}
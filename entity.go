@@ -1,16 +1,14 @@
 package mysql
 
 import (
-	"encoding/json"
 	"reflect"
 	"strings"
 
 	"github.com/pkg/errors"
-	"gopkg.in/yaml.v3"
 )
 
 type entityField struct {
-	FieldIndex      int
+	FieldIndex      []int
 	ColumnName      string
 	FieldSchema     *Field
 	SerializeMethod uint8
@@ -20,28 +18,86 @@ type Entity[T interface{}] struct {
 	fields         []*entityField
 	tableNameStr   string
 	columnNamesStr string
+	fieldsByColumn map[string]*Field // of the schema, to check the columns of conditions
+	primaryColumns []string          // of the schema, the default order of SelectCursor
+	aliases        map[string]*Field // expression fields by alias, see expr
 	dbRead         *DB
 }
 
-var (
-	entityCache = make(map[reflect.Type]interface{})
-)
-
+// GetEntity returns the entity of T mapped onto the table of schema, it is cached in the schema,
+// so the same struct type could be used by the schemas of many tables. It is safe for concurrent use.
+// A field could be mapped onto an SQL expression instead of a column by the expr option, e.g.
+// `db:"total expr(SUM(amount))"`, the options of the tag other than the column are applied to the expression,
+// so it could be scanned by json, nullzero, etc. The expression is selected as the alias, which could be used
+// by GroupBy, Having and OrderBy of the Query.
+// It panics if T could not be mapped onto the table, see TryGetEntity.
 func GetEntity[T interface{}, S interface{}](schema *Schema[S]) *Entity[T] {
+	entity, e := getEntity[T](schema)
+	if e != nil {
+		panic(e)
+	}
+	return entity
+}
+
+// TryGetEntity is GetEntity returning an error if T could not be mapped onto the table
+func TryGetEntity[T interface{}, S interface{}](schema *Schema[S]) (*Entity[T], error) {
+	return getEntity[T](schema)
+}
+
+func getEntity[T interface{}, S interface{}](schema *Schema[S]) (*Entity[T], error) {
 	t := reflect.TypeOf((*T)(nil)).Elem()
-	if entity, ok := entityCache[t]; ok {
-		return entity.(*Entity[T])
+	schema.entitiesMu.RLock()
+	entity, ok := schema.entities[t]
+	schema.entitiesMu.RUnlock()
+	if ok {
+		return entity.(*Entity[T]), nil
+	}
+
+	schema.entitiesMu.Lock()
+	defer schema.entitiesMu.Unlock()
+	if entity, ok := schema.entities[t]; ok {
+		return entity.(*Entity[T]), nil
+	}
+	if schema.entities == nil {
+		schema.entities = make(map[reflect.Type]interface{})
 	}
-	entity := &Entity[T]{
+	ent := &Entity[T]{
 		fields:         make([]*entityField, 0),
 		tableNameStr:   schema.Name,
+		fieldsByColumn: schema.FieldsByColumn,
 		columnNamesStr: "",
 		dbRead:         schema.dbRead,
 	}
+	for _, field := range schema.primaryFields {
+		ent.primaryColumns = append(ent.primaryColumns, field.Name)
+	}
+	if e := ent.fieldsFromType(t, nil, "", schema.FieldsByColumn, map[reflect.Type]bool{t: true}); e != nil {
+		return nil, e
+	}
+	if ent.columnNamesStr == "" {
+		return nil, errors.New("no column of " + t.Name() + " in " + schema.Name)
+	}
+	ent.columnNamesStr = ent.columnNamesStr[:len(ent.columnNamesStr)-1] // remove last comma
+	schema.entities[t] = ent
+	return ent, nil
+}
+
+func (entity *Entity[T]) fieldsFromType(t reflect.Type, index []int, prefix string, fieldsByColumn map[string]*Field, visiting map[reflect.Type]bool) error {
 	for i := 0; i < t.NumField(); i++ {
 		fieldType := t.Field(i)
-		if tag := tryGetFieldNameFromTag(fieldType.Tag); tag != "" {
-			field := &entityField{FieldIndex: i}
+		if inline, inlinePrefix, ok := inlineStruct(fieldType); ok {
+			if visiting[inline] {
+				return errors.New("recursive struct " + t.Name() + "." + fieldType.Name)
+			}
+			visiting[inline] = true
+			if e := entity.fieldsFromType(inline, appendIndex(index, i), prefix+inlinePrefix, fieldsByColumn, visiting); e != nil {
+				return e
+			}
+			delete(visiting, inline)
+			continue
+		}
+		if tag := tryGetFieldNameFromTag(fieldType.Tag); tag != "" && fieldType.Name != "_" {
+			field := &entityField{FieldIndex: appendIndex(index, i)}
 			if tag != "" {
 				tag_parts := strings.Split(tag, " ")
 				field.ColumnName = tag_parts[0]
@@ -52,9 +108,35 @@ func GetEntity[T interface{}, S interface{}](schema *Schema[S]) *Entity[T] {
 			if field.ColumnName == "" {
 				field.ColumnName = camelToSnake(fieldType.Name)
 			}
-			fs, ok := schema.FieldsByColumn[field.ColumnName]
+			field.ColumnName = prefix + field.ColumnName
+			tagItems, e := parseTagArguments(tag)
+			if e != nil {
+				return tagError(e, t.Name()+"."+fieldType.Name)
+			}
+			if hasTagOption(tagItems, "expr") {
+				fs := &Field{}
+				if e := fs.fromTagItems(tag, tagItems, fieldType); e != nil {
+					return tagError(e, t.Name()+"."+fieldType.Name)
+				}
+				if fs.Expr != "" {
+					fs.Name = field.ColumnName
+					if e := fs.CompleteWithType(fieldType); e != nil {
+						return tagError(e, t.Name()+"."+fieldType.Name)
+					}
+					field.FieldSchema = fs
+					field.SerializeMethod = fs.SerializeMethod
+					if entity.aliases == nil {
+						entity.aliases = make(map[string]*Field)
+					}
+					entity.aliases[field.ColumnName] = fs
+					entity.fields = append(entity.fields, field)
+					entity.columnNamesStr += "(" + fs.Expr + ") AS `" + field.ColumnName + "`,"
+					continue
+				}
+			}
+			fs, ok := fieldsByColumn[field.ColumnName]
 			if !ok {
-				panic("field of " + t.Name() + " not found in schema: " + field.ColumnName)
+				return errors.New("column of " + t.Name() + "." + fieldType.Name + " not found in schema: " + field.ColumnName)
 			}
 			field.FieldSchema = fs
 			field.SerializeMethod = fs.SerializeMethod
@@ -62,9 +144,28 @@ func GetEntity[T interface{}, S interface{}](schema *Schema[S]) *Entity[T] {
 			entity.columnNamesStr += "`" + field.ColumnName + "`,"
 		}
 	}
-	entity.columnNamesStr = entity.columnNamesStr[:len(entity.columnNamesStr)-1] // remove last comma
-	entityCache[t] = entity
-	return entity
+	return nil
+}
+
+func (field *entityField) decoder() func(src interface{}, dest interface{}) error {
+	if field.FieldSchema.columnType != nil {
+		return field.FieldSchema.columnType.Decode
+	}
+	return nil
+}
+
+// decodeField decodes the value scanned from the driver into the field of a registered type
+func decodeField(field *entityField, fv reflect.Value, src interface{}, decode func(src interface{}, dest interface{}) error) error {
+	if fv.Kind() == reflect.Ptr && fv.Type() != field.FieldSchema.columnType.goType {
+		// registered as the element type of a pointer field
+		if src == nil {
+			fv.SetZero()
+			return nil
+		}
+		fv.Set(reflect.New(fv.Type().Elem()))
+		fv = fv.Elem()
+	}
+	return decode(src, fv.Addr().Interface())
 }
 
 type rowLike interface {
@@ -75,10 +176,16 @@ func (ent *Entity[T]) scan(r rowLike, data *T) error {
 	val := reflect.ValueOf(data).Elem()
 	args := make([]interface{}, len(ent.fields))
 	for i, field := range ent.fields {
-		if field.SerializeMethod == JSON || field.SerializeMethod == YAML {
-			args[i] = new(string)
+		if field.FieldSchema.Encrypted {
+			args[i] = new([]byte)
+		} else if field.decoder() != nil {
+			args[i] = new(interface{})
+		} else if field.FieldSchema.serializer() != nil {
+			args[i] = new([]byte)
+		} else if field.FieldSchema.NullAsZero {
+			args[i] = reflect.New(reflect.PointerTo(fieldByIndex(val, field.FieldIndex).Type())).Interface()
 		} else {
-			args[i] = val.Field(field.FieldIndex).Addr().Interface()
+			args[i] = fieldByIndex(val, field.FieldIndex).Addr().Interface()
 		}
 	}
 	e := r.Scan(args...)
@@ -86,10 +193,42 @@ func (ent *Entity[T]) scan(r rowLike, data *T) error {
 		return errors.Wrap(e, "scan failed")
 	}
 	for i, field := range ent.fields {
-		if field.SerializeMethod == JSON {
-			json.Unmarshal([]byte(*args[i].(*string)), val.Field(field.FieldIndex).Addr().Interface())
-		} else if field.SerializeMethod == YAML {
-			yaml.Unmarshal([]byte(*args[i].(*string)), val.Field(field.FieldIndex).Addr().Interface())
+		if field.FieldSchema.Encrypted {
+			b := *args[i].(*[]byte)
+			if len(b) == 0 {
+				fieldByIndex(val, field.FieldIndex).SetZero() // NULL or empty
+				continue
+			}
+			plain, e := decryptValue(field.ColumnName, b)
+			if e != nil {
+				return errors.Wrap(e, "Decrypt "+field.ColumnName+" failed")
+			}
+			if decode := field.decoder(); decode != nil {
+				e = decodeField(field, fieldByIndex(val, field.FieldIndex), plain, decode)
+			} else if ser := field.FieldSchema.serializer(); ser != nil {
+				e = ser.Unmarshal(plain, fieldByIndex(val, field.FieldIndex).Addr().Interface())
+			} else {
+				e = setPlainBytes(fieldByIndex(val, field.FieldIndex), plain)
+			}
+			if e != nil {
+				return errors.Wrap(e, "Decrypt "+field.ColumnName+" failed")
+			}
+		} else if decode := field.decoder(); decode != nil {
+			if e := decodeField(field, fieldByIndex(val, field.FieldIndex), *args[i].(*interface{}), decode); e != nil {
+				return errors.Wrap(e, "Decode "+field.ColumnName+" failed")
+			}
+		} else if ser := field.FieldSchema.serializer(); ser != nil {
+			if b := *args[i].(*[]byte); len(b) == 0 {
+				fieldByIndex(val, field.FieldIndex).SetZero() // NULL or empty
+			} else if e := ser.Unmarshal(b, fieldByIndex(val, field.FieldIndex).Addr().Interface()); e != nil {
+				return errors.Wrap(e, "Deserialize "+field.ColumnName+" failed")
+			}
+		} else if field.FieldSchema.NullAsZero {
+			if p := reflect.ValueOf(args[i]).Elem(); p.IsNil() {
+				fieldByIndex(val, field.FieldIndex).SetZero()
+			} else {
+				fieldByIndex(val, field.FieldIndex).Set(p.Elem())
+			}
 		}
 	}
 	return nil
@@ -0,0 +1,77 @@
+package mysql
+
+import (
+	"context"
+	"iter"
+
+	"github.com/pkg/errors"
+)
+
+// IterEx returns an iterator over the records, which are scanned one by one instead of being loaded at once.
+// The query is made when the iteration starts, and the rows are closed when it ends, breaks or the context is done.
+// An error is yielded as the last pair of the iteration. If reuse is true, the same T is reset and yielded for
+// every record to avoid allocations, so the record should not be kept after the iteration step.
+func (ent *Entity[T]) IterEx(ctx context.Context, db IDBLike, reuse bool, where any, args ...any) iter.Seq2[*T, error] {
+	return func(yield func(*T, error) bool) {
+		cond, tail, args, e := buildWhere(where, args, ent.fieldsByColumn, ent.aliases)
+		if e != nil {
+			yield(nil, e)
+			return
+		}
+		sql := "SELECT " + ent.columnNamesStr + " FROM `" + ent.tableNameStr + "`" + cond + tail
+		rows, e := db.QueryContext(ctx, sql, args...)
+		if e != nil {
+			yield(nil, errors.Wrap(e, "Iter failed"))
+			return
+		}
+		defer rows.Close()
+		var v *T
+		if reuse {
+			v = new(T)
+		}
+		for rows.Next() {
+			if reuse {
+				var zero T
+				*v = zero
+			} else {
+				v = new(T)
+			}
+			if e := ent.scan(rows, v); e != nil {
+				yield(nil, errors.Wrap(e, "Iter failed"))
+				return
+			}
+			if !yield(v, nil) {
+				return
+			}
+			if e := ctx.Err(); e != nil {
+				yield(nil, e)
+				return
+			}
+		}
+		if e := rows.Err(); e != nil {
+			yield(nil, errors.Wrap(e, "Iter failed"))
+		}
+	}
+}
+
+func (ent *Entity[T]) Iter(ctx context.Context, reuse bool, where any, args ...any) iter.Seq2[*T, error] {
+	return ent.IterEx(ctx, ent.dbRead.Ctx, reuse, where, args...)
+}
+
+// ForEachEx calls fn with the records one by one, see IterEx. The iteration stops at the first error of fn,
+// which is returned.
+func (ent *Entity[T]) ForEachEx(ctx context.Context, db IDBLike, reuse bool, fn func(*T) error, where any, args ...any) error {
+	for v, e := range ent.IterEx(ctx, db, reuse, where, args...) {
+		if e != nil {
+			return e
+		}
+		if e := fn(v); e != nil {
+			return e
+		}
+	}
+	return nil
+}
+
+func (ent *Entity[T]) ForEach(ctx context.Context, reuse bool, fn func(*T) error, where any, args ...any) error {
+	return ent.ForEachEx(ctx, ent.dbRead.Ctx, reuse, fn, where, args...)
+}
@@ -3,15 +3,17 @@ package mysql
 import (
 	"context"
 	drv "database/sql"
+	"strings"
 
 	"github.com/pkg/errors"
 )
 
-func (ent *Entity[T]) SelectOneEx(ctx context.Context, db IDBLike, where string, args ...any) (*T, error) {
-	sql := "SELECT " + ent.columnNamesStr + " FROM `" + ent.tableNameStr + "`"
-	if where != "" {
-		sql += " WHERE " + where
+func (ent *Entity[T]) SelectOneEx(ctx context.Context, db IDBLike, where any, args ...any) (*T, error) {
+	cond, tail, args, e := buildWhere(where, args, ent.fieldsByColumn, ent.aliases)
+	if e != nil {
+		return nil, e
 	}
+	sql := "SELECT " + ent.columnNamesStr + " FROM `" + ent.tableNameStr + "`" + cond + tail
 	row := db.QueryRowContext(ctx, sql, args...)
 	v := new(T)
 	if e := ent.scan(row, v); e != nil {
@@ -23,15 +25,16 @@ func (ent *Entity[T]) SelectOneEx(ctx context.Context, db IDBLike, where string,
 	return v, nil
 }
 
-func (ent *Entity[T]) SelectOne(ctx context.Context, where string, args ...any) (*T, error) {
+func (ent *Entity[T]) SelectOne(ctx context.Context, where any, args ...any) (*T, error) {
 	return ent.SelectOneEx(ctx, ent.dbRead.Ctx, where, args...)
 }
 
-func (ent *Entity[T]) SelectEx(ctx context.Context, db IDBLike, where string, args ...any) ([]*T, error) {
-	sql := "SELECT " + ent.columnNamesStr + " FROM `" + ent.tableNameStr + "`"
-	if where != "" {
-		sql += " WHERE " + where
+func (ent *Entity[T]) SelectEx(ctx context.Context, db IDBLike, where any, args ...any) ([]*T, error) {
+	cond, tail, args, e := buildWhere(where, args, ent.fieldsByColumn, ent.aliases)
+	if e != nil {
+		return nil, e
 	}
+	sql := "SELECT " + ent.columnNamesStr + " FROM `" + ent.tableNameStr + "`" + cond + tail
 	rows, e := db.QueryContext(ctx, sql, args...)
 	if e != nil {
 		return nil, e
@@ -48,42 +51,58 @@ func (ent *Entity[T]) SelectEx(ctx context.Context, db IDBLike, where string, ar
 	return result, nil
 }
 
-func (ent *Entity[T]) Select(ctx context.Context, where string, args ...any) ([]*T, error) {
+func (ent *Entity[T]) Select(ctx context.Context, where any, args ...any) ([]*T, error) {
 	return ent.SelectEx(ctx, ent.dbRead.Ctx, where, args...)
 }
 
-// SelectPage selects a page of records from the database.
+// SelectPageEx selects a page of records with the exact total count, see SelectPageWithEx.
 // page_idx shoud be 1-based.
-// Return: records, current page index, page size, page_count, total count, error
-func (ent *Entity[T]) SelectPageEx(ctx context.Context, db IDBLike, page_idx, page_size int64, where string, args ...any) ([]*T, int64, int64, int64, int64, error) {
-	sql := "SELECT count(*) FROM `" + ent.tableNameStr + "`"
-	if where != "" {
-		sql += " WHERE " + where
+func (ent *Entity[T]) SelectPageEx(ctx context.Context, db IDBLike, page_idx, page_size int64, where any, args ...any) (*Page[T], error) {
+	return ent.SelectPageWithEx(ctx, db, CountExact, page_idx, page_size, where, args...)
+}
+
+func (ent *Entity[T]) SelectPage(ctx context.Context, page_idx, page_size int64, where any, args ...any) (*Page[T], error) {
+	return ent.SelectPageEx(ctx, ent.dbRead.Ctx, page_idx, page_size, where, args...)
+}
+
+// SelectPageWithEx selects a page of records from the database, the total count is got by the strategy.
+// page_idx shoud be 1-based, the page after the last one is empty.
+// CountExact makes two queries, CountWindow makes one more query only if the page is after the last one,
+// CountEstimate reads the statistics of the table which could be far from the real count,
+// CountNone skips the total count, only HasNext of the page is set.
+func (ent *Entity[T]) SelectPageWithEx(ctx context.Context, db IDBLike, count CountStrategy, page_idx, page_size int64, where any, args ...any) (*Page[T], error) {
+	cond, order, args, e := buildWhere(where, args, ent.fieldsByColumn, ent.aliases)
+	if e != nil {
+		return nil, e
 	}
-	var cnt int64
-	vargs := make([]interface{}, 0, len(args)+2)
-	vargs = append(vargs, args...)
-	if e := db.QueryRowContext(ctx, sql, args...).Scan(&cnt); e != nil {
-		return nil, 0, 0, 0, 0, errors.Wrap(e, "SelectPage failed")
+	if strings.Contains(order, " LIMIT ") {
+		return nil, errors.New("LIMIT could not be used with SelectPage")
 	}
-
 	if page_size < 1 {
 		page_size = 1
 	}
-
-	if cnt == 0 {
-		return make([]*T, 0), 1, page_size, 0, 0, nil
-	}
-
-	page_count := cnt / page_size
-	if cnt%page_size > 0 {
-		page_count++
-	}
-
 	if page_idx < 1 {
 		page_idx = 1
 	}
 
+	total := int64(-1)
+	switch count {
+	case CountExact:
+		if total, e = ent.countEx(ctx, db, cond, args, isGrouped(where)); e != nil {
+			return nil, errors.Wrap(e, "SelectPage failed")
+		}
+		if total == 0 {
+			return newPage(make([]*T, 0), page_idx, page_size, 0, false), nil
+		}
+	case CountEstimate:
+		if total, e = estimateCount(ctx, db, ent.tableNameStr, ent.columnNamesStr, cond, args); e != nil {
+			return nil, errors.Wrap(e, "SelectPage failed")
+		}
+	case CountWindow, CountNone:
+	default:
+		return nil, errors.New("Unknown count strategy")
+	}
+
 	/* Allow page_idx > page_count
 	if page_idx > page_count {
 		page_idx = page_count
@@ -91,29 +110,81 @@ func (ent *Entity[T]) SelectPageEx(ctx context.Context, db IDBLike, page_idx, pa
 	*/
 
 	offset := (page_idx - 1) * page_size
-
-	vargs = append(vargs, offset, page_size)
-	sql = "SELECT " + ent.columnNamesStr + " FROM `" + ent.tableNameStr + "` "
-	if where != "" {
-		sql += "WHERE " + where
+	limit := page_size
+	columns := ent.columnNamesStr
+	if count == CountEstimate || count == CountNone {
+		limit++ // one more row to know if there is a next page
+	} else if count == CountWindow {
+		columns += ",COUNT(*) OVER()"
 	}
-	sql += " LIMIT ?, ?"
+
+	vargs := make([]interface{}, 0, len(args)+2)
+	vargs = append(vargs, args...)
+	vargs = append(vargs, offset, limit)
+	sql := "SELECT " + columns + " FROM `" + ent.tableNameStr + "`" + cond + order + " LIMIT ?, ?"
 	rows, e := db.QueryContext(ctx, sql, vargs...)
 	if e != nil {
-		return nil, 0, 0, 0, 0, errors.Wrap(e, "SelectPage failed")
+		return nil, errors.Wrap(e, "SelectPage failed")
 	}
 	defer rows.Close()
+	var r rowLike = rows
+	var window int64
+	if count == CountWindow {
+		r = scanWith{r: rows, extra: []any{&window}}
+	}
 	result := make([]*T, 0)
 	for rows.Next() {
 		v := new(T)
-		if e := ent.scan(rows, v); e != nil {
-			return nil, 0, 0, 0, 0, errors.Wrap(e, "SelectPage failed")
+		if e := ent.scan(r, v); e != nil {
+			return nil, errors.Wrap(e, "SelectPage failed")
 		}
 		result = append(result, v)
 	}
-	return result, page_idx, page_size, page_count, cnt, nil
+	if e := rows.Err(); e != nil {
+		return nil, errors.Wrap(e, "SelectPage failed")
+	}
+
+	switch count {
+	case CountWindow:
+		total = window
+		if len(result) == 0 && offset > 0 { // no row to carry the count after the last page
+			if total, e = ent.countEx(ctx, db, cond, args, isGrouped(where)); e != nil {
+				return nil, errors.Wrap(e, "SelectPage failed")
+			}
+		}
+	case CountEstimate, CountNone:
+		hasNext := int64(len(result)) > page_size
+		if hasNext {
+			result = result[:page_size]
+		}
+		if count == CountEstimate && total < offset+int64(len(result)) {
+			total = offset + int64(len(result)) // at least the rows seen
+			if hasNext {
+				total++
+			}
+		}
+		page := newPage(result, page_idx, page_size, total, count == CountEstimate)
+		page.HasNext = hasNext
+		return page, nil
+	}
+	return newPage(result, page_idx, page_size, total, false), nil
 }
 
-func (ent *Entity[T]) SelectPage(ctx context.Context, page_idx, page_size int64, where string, args ...any) ([]*T, int64, int64, int64, int64, error) {
-	return ent.SelectPageEx(ctx, ent.dbRead.Ctx, page_idx, page_size, where, args...)
+func (ent *Entity[T]) SelectPageWith(ctx context.Context, count CountStrategy, page_idx, page_size int64, where any, args ...any) (*Page[T], error) {
+	return ent.SelectPageWithEx(ctx, ent.dbRead.Ctx, count, page_idx, page_size, where, args...)
+}
+
+// countEx returns the count of the rows matching the WHERE clause rendered by buildWhere,
+// or the count of the groups if grouped. The rows selected by an entity with expressions are counted,
+// e.g. an aggregate without GROUP BY selects one row.
+func (ent *Entity[T]) countEx(ctx context.Context, db IDBLike, cond string, args []any, grouped bool) (int64, error) {
+	sql := "SELECT count(*) FROM `" + ent.tableNameStr + "`" + cond
+	if grouped || len(ent.aliases) > 0 {
+		sql = "SELECT count(*) FROM (SELECT " + ent.columnNamesStr + " FROM `" + ent.tableNameStr + "`" + cond + ") `g`"
+	}
+	var cnt int64
+	if e := db.QueryRowContext(ctx, sql, args...).Scan(&cnt); e != nil {
+		return 0, e
+	}
+	return cnt, nil
 }
@@ -0,0 +1,47 @@
+package mysql
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+	"testing"
+)
+
+// testDecimal is passed to the driver directly, without a registered column type
+type testDecimal string
+
+func (d *testDecimal) Scan(src any) error {
+	*d = testDecimal(src.([]byte))
+	return nil
+}
+
+func (d testDecimal) Value() (driver.Value, error) { return string(d), nil }
+
+func TestEntityExpressions(t *testing.T) {
+	type order struct {
+		ID     int64  `db:"id pk"`
+		Status string `db:"status varchar(16) def('expr(x)')"`
+		Amount int64  `db:"amount"`
+	}
+	type summary struct {
+		Status string          `db:"status comment(expr(1))"`
+		Total  sql.NullFloat64 `db:"total expr(SUM(amount))"`
+		Count  int64           `db:"cnt expr(COUNT(*))"`
+		Avg    testDecimal     `db:"avg expr(AVG(amount))"`
+	}
+	sc := &Schema[order]{Name: "orders"}
+	if e := sc.fromType(reflect.TypeOf((*order)(nil))); e != nil {
+		t.Fatal(e)
+	}
+	ent, e := TryGetEntity[summary](sc)
+	if e != nil {
+		t.Fatal(e)
+	}
+	want := "`status`,(SUM(amount)) AS `total`,(COUNT(*)) AS `cnt`,(AVG(amount)) AS `avg`"
+	if ent.columnNamesStr != want {
+		t.Errorf("got %s, want %s", ent.columnNamesStr, want)
+	}
+	if len(ent.aliases) != 3 || ent.aliases["total"] == nil || ent.aliases["cnt"] == nil {
+		t.Errorf("got aliases %v, want total, cnt and avg", ent.aliases)
+	}
+}
@@ -1,13 +1,105 @@
 package mysql
 
-import "github.com/pkg/errors"
+import (
+	"fmt"
+	"strings"
+
+	drv "github.com/go-sql-driver/mysql"
+	"github.com/pkg/errors"
+)
 
 var (
-	ErrInvalidData    = errors.New("invalid data")
-	ErrNotFound       = errors.New("not found")
-	ErrNoDataInfo     = errors.New("dataInfo is missing, call Reflect on Schema first")
-	ErrNotReady       = errors.New("stmtInsert is not ready, call Prepare on Schema first")
-	ErrNoPrimaryKey   = errors.New("no primary key")
-	ErrNoRowsAffected = errors.New("no rows affected")
-	ErrDuplicateKey   = errors.New("duplicate key")
+	ErrInvalidData     = errors.New("invalid data")
+	ErrNotFound        = errors.New("not found")
+	ErrNoDataInfo      = errors.New("dataInfo is missing, call Reflect on Schema first")
+	ErrNotReady        = errors.New("stmtInsert is not ready, call Prepare on Schema first")
+	ErrNoPrimaryKey    = errors.New("no primary key")
+	ErrNoRowsAffected  = errors.New("no rows affected")
+	ErrDuplicateKey    = errors.New("duplicate key")
+	ErrCheckViolation  = errors.New("check constraint violated")
+	ErrReadOnly        = errors.New("schema is read-only")
+	ErrUnmanaged       = errors.New("schema is unmanaged")
+	ErrSchemaMismatch  = errors.New("schema mismatch")
+	ErrUnsafeMigration = errors.New("unsafe migration")
+	ErrInvalidTag      = errors.New("invalid db tag")
+	ErrInvalidCursor   = errors.New("invalid cursor")
+	ErrNoCursorKey     = errors.New("cursor key is not set, call SetCursorKey first")
 )
+
+// CheckViolationError is returned when a write violates a CHECK constraint,
+// errors.Is(e, ErrCheckViolation) could be used to test it.
+type CheckViolationError struct {
+	Constraint string // Name of the violated constraint
+	Err        error  // Original error from the driver
+}
+
+func (e *CheckViolationError) Error() string {
+	return fmt.Sprintf("check constraint %s violated: %v", e.Constraint, e.Err)
+}
+
+func (e *CheckViolationError) Unwrap() error {
+	return e.Err
+}
+
+func (e *CheckViolationError) Is(target error) bool {
+	return target == ErrCheckViolation
+}
+
+// TagError is returned when a db tag could not be parsed, errors.Is(e, ErrInvalidTag) could be used to test it.
+type TagError struct {
+	Field string // Name of the struct field, e.g. Order.Amount
+	Tag   string // The db tag
+	Pos   int    // Byte offset of the problem in Tag
+	Msg   string
+}
+
+func (e *TagError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("db tag `%s` at %d: %s", e.Tag, e.Pos, e.Msg)
+	}
+	return fmt.Sprintf("%s: db tag `%s` at %d: %s", e.Field, e.Tag, e.Pos, e.Msg)
+}
+
+func (e *TagError) Is(target error) bool {
+	return target == ErrInvalidTag
+}
+
+// TagErrors are the problems reported by ValidateStruct, returned by OpenSchema in strict mode
+type TagErrors []*TagError
+
+func (e TagErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, te := range e {
+		msgs[i] = te.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+func (e TagErrors) Is(target error) bool {
+	return target == ErrInvalidTag
+}
+
+// tagError sets the field name of a TagError returned by the tag parser
+func tagError(e error, field string) error {
+	if te, ok := e.(*TagError); ok {
+		te.Field = field
+	}
+	return e
+}
+
+// wrapExecError converts the known driver errors of a write into the errors of this package
+func wrapExecError(e error, message string) error {
+	if mysqlErr, ok := e.(*drv.MySQLError); ok {
+		switch mysqlErr.Number {
+		case 1062:
+			return ErrDuplicateKey
+		case 3819, 4025: // MySQL, MariaDB
+			name := ""
+			if parts := strings.FieldsFunc(mysqlErr.Message, func(r rune) bool { return r == '\'' || r == '`' }); len(parts) > 1 {
+				name = parts[1]
+			}
+			return &CheckViolationError{Constraint: name, Err: e}
+		}
+	}
+	return errors.Wrap(e, message)
+}
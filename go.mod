@@ -1,6 +1,6 @@
 module github.com/acsl-go/mysql
 
-go 1.21.4
+go 1.23.0
 
 require (
 	github.com/acsl-go/logger v0.0.3
@@ -1,17 +1,27 @@
 package mysql
 
 import (
+	"context"
 	"database/sql"
 
 	"github.com/acsl-go/logger"
 	"github.com/pkg/errors"
 )
 
+// PrepareStmt prepares the statement, the process is terminated if it fails, see PrepareStmtContext
 func PrepareStmt(db *DB, query string) *sql.Stmt {
-	stmt, e := db.Ctx.Prepare(query)
+	stmt, e := PrepareStmtContext(context.Background(), db, query)
 	if e != nil {
-		logger.Fatal("%+v", errors.Wrap(e, "PrepareStmt Failed"))
+		logger.Fatal("%+v", e)
 		panic(e)
 	}
 	return stmt
 }
+
+func PrepareStmtContext(ctx context.Context, db *DB, query string) (*sql.Stmt, error) {
+	stmt, e := db.Ctx.PrepareContext(ctx, query)
+	if e != nil {
+		return nil, errors.Wrap(e, "PrepareStmt Failed")
+	}
+	return stmt, nil
+}
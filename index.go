@@ -27,3 +27,12 @@ func (idx *Index) Equal(other *Index) bool {
 	}
 	return true
 }
+
+func (idx *Index) hasColumn(column string) bool {
+	for _, c := range idx.Columns {
+		if c == column {
+			return true
+		}
+	}
+	return false
+}
@@ -5,6 +5,9 @@ import (
 	"strings"
 )
 
+// maxIdentifierLength is the limit of the names of MySQL tables, columns and constraints
+const maxIdentifierLength = 64
+
 func camelToSnake(s string) string {
 	var sb strings.Builder
 	for i, c := range s {
@@ -39,3 +42,73 @@ func tryGetFieldNameFromOtherTag(tag reflect.StructTag) string {
 	}
 	return ""
 }
+
+func removeString(s []string, v string) []string {
+	for i, item := range s {
+		if item == v {
+			return append(s[:i], s[i+1:]...)
+		}
+	}
+	return s
+}
+
+func insertString(s []string, i int, v string) []string {
+	if i > len(s) {
+		i = len(s)
+	}
+	s = append(s, "")
+	copy(s[i+1:], s[i:])
+	s[i] = v
+	return s
+}
+
+// fieldByIndex returns the nested field of v by the index path, nil embedded pointers on the path are allocated
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+// fieldValueByIndex returns the nested field of v by the index path, or the zero value if a nil pointer is on the path
+func fieldValueByIndex(v reflect.Value, index []int) reflect.Value {
+	if f, e := v.FieldByIndexErr(index); e == nil {
+		return f
+	}
+	return reflect.Zero(v.Type().FieldByIndex(index).Type)
+}
+
+// inlineStruct returns the struct type to be flattened into columns, and the column prefix.
+// Anonymous structs without a db tag are flattened, as well as the fields tagged with `db:"inline(<prefix>)"`.
+func inlineStruct(structField reflect.StructField) (reflect.Type, string, bool) {
+	t := structField.Type
+	if t.Kind() == reflect.Ptr {
+		if !structField.IsExported() {
+			return nil, "", false // could not be allocated by reflection
+		}
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || t == timeType {
+		return nil, "", false
+	}
+	tag, ok := structField.Tag.Lookup("db")
+	if !ok {
+		return t, "", structField.Anonymous
+	}
+	items, _ := parseTagArguments(tag) // malformed tags are reported when parsed as columns
+	if len(items) > 0 && items[0].Name == "inline" {
+		return t, items[0].Value, true
+	}
+	return nil, "", false
+}
+
+// appendIndex returns a new index path, the original one is never modified
+func appendIndex(index []int, i int) []int {
+	return append(append(make([]int, 0, len(index)+1), index...), i)
+}
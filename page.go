@@ -0,0 +1,106 @@
+package mysql
+
+import (
+	"context"
+	drv "database/sql"
+
+	"github.com/pkg/errors"
+)
+
+// CountStrategy is how SelectPage gets the total count of the records
+type CountStrategy uint8
+
+const (
+	CountExact    CountStrategy = iota // SELECT count(*) before the page query
+	CountWindow                        // COUNT(*) OVER() in the page query, requires MySQL 8
+	CountEstimate                      // TABLE_ROWS of information_schema.TABLES, or EXPLAIN if there is a condition
+	CountNone                          // No total count, only whether there is a next page
+)
+
+// Page is a page of records selected by SelectPage
+type Page[T interface{}] struct {
+	Items     []*T
+	Index     int64 // 1-based page index
+	Size      int64 // Page size
+	Count     int64 // Page count, -1 if the total count is unknown
+	Total     int64 // Total count of the records, -1 if unknown
+	Estimated bool  // Count and Total are estimated
+	HasNext   bool  // There are records after the page
+}
+
+func newPage[T interface{}](items []*T, idx, size, total int64, estimated bool) *Page[T] {
+	page := &Page[T]{
+		Items:     items,
+		Index:     idx,
+		Size:      size,
+		Count:     -1,
+		Total:     total,
+		Estimated: estimated,
+	}
+	if total >= 0 {
+		page.Count = total / size
+		if total%size > 0 {
+			page.Count++
+		}
+		page.HasNext = idx < page.Count
+	}
+	return page
+}
+
+// scanWith scans the row with extra destinations after the ones passed to Scan
+type scanWith struct {
+	r     rowLike
+	extra []any
+}
+
+func (s scanWith) Scan(dest ...any) error {
+	return s.r.Scan(append(dest, s.extra...)...)
+}
+
+// estimateCount returns the estimated count of the rows matching cond by the statistics of the table
+func estimateCount(ctx context.Context, db IDBLike, table string, selected string, cond string, args []any) (int64, error) {
+	if cond == "" {
+		var n drv.NullInt64
+		if e := db.QueryRowContext(ctx, "SELECT `TABLE_ROWS` FROM `information_schema`.`TABLES` WHERE `TABLE_SCHEMA` = DATABASE() AND `TABLE_NAME` = ?", table).Scan(&n); e != nil {
+			return 0, errors.Wrap(e, "Estimate count failed")
+		}
+		return n.Int64, nil
+	}
+
+	rows, e := db.QueryContext(ctx, "EXPLAIN SELECT "+selected+" FROM `"+table+"`"+cond, args...)
+	if e != nil {
+		return 0, errors.Wrap(e, "Estimate count failed")
+	}
+	defer rows.Close()
+	columns, e := rows.Columns()
+	if e != nil {
+		return 0, errors.Wrap(e, "Estimate count failed")
+	}
+	if !rows.Next() {
+		return 0, errors.Wrap(rows.Err(), "Estimate count failed")
+	}
+	values := make([]any, len(columns))
+	dest := make([]any, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if e := rows.Scan(dest...); e != nil {
+		return 0, errors.Wrap(e, "Estimate count failed")
+	}
+	n, filtered := 0.0, 100.0
+	for i, column := range columns {
+		v := values[i]
+		if b, ok := v.([]byte); ok {
+			v = string(b)
+		}
+		switch column {
+		case "rows":
+			n, _ = toFloat64(v)
+		case "filtered":
+			if f, ok := toFloat64(v); ok {
+				filtered = f
+			}
+		}
+	}
+	return int64(n * filtered / 100), nil
+}
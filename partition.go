@@ -0,0 +1,155 @@
+package mysql
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Partition declares the partitioning of a table
+//
+//	RANGE, RANGE COLUMNS	- Definitions with `LESS THAN (<value>)`, and/or Rotation
+//	LIST, LIST COLUMNS		- Definitions with `IN (<values>)`
+//	HASH, KEY				- Count partitions, LINEAR HASH and LINEAR KEY are supported as well
+type Partition struct {
+	Type        string // Partitioning method, e.g. RANGE COLUMNS
+	Expr        string // Partitioning expression or column list, e.g. created_at or TO_DAYS(created_at)
+	Count       int    // Number of partitions for HASH and KEY
+	Definitions []*PartitionDef
+	Rotation    *PartitionRotation
+}
+
+// PartitionDef defines a partition of RANGE or LIST partitioning
+type PartitionDef struct {
+	Name   string
+	Values string // e.g. `LESS THAN (100)`, `LESS THAN MAXVALUE` or `IN (1,2,3)`
+}
+
+type PartitionPeriod uint8
+
+const (
+	PartitionDaily PartitionPeriod = iota + 1
+	PartitionWeekly
+	PartitionMonthly
+	PartitionYearly
+)
+
+// PartitionRotation manages time based RANGE partitions, one partition per period.
+// The partitions are named as `p<period start>`, e.g. p20261019 (daily, weekly), p202610 (monthly) and p2026 (yearly),
+// and hold the rows less than the start of the next period.
+// The table should not have a MAXVALUE partition, new partitions are appended at the end.
+type PartitionRotation struct {
+	Period    PartitionPeriod
+	Ahead     int                      // Number of partitions created in advance after the current one
+	Retention int                      // Number of past partitions to be kept before the current one, 0 to keep all
+	Location  *time.Location           // Time zone of the periods, time.Local if nil
+	Bound     func(t time.Time) string // SQL value of the bound, '2006-01-02 15:04:05' if nil, which fits RANGE COLUMNS on date/datetime columns
+}
+
+// TablePartitioner could be implemented by the struct type of a schema to declare the partitioning of the table
+type TablePartitioner interface {
+	TablePartition() *Partition
+}
+
+func (p *Partition) method() string {
+	return strings.ToUpper(strings.Join(strings.Fields(p.Type), " "))
+}
+
+func (p *Partition) isHash() bool {
+	return strings.HasSuffix(p.method(), "HASH") || strings.HasSuffix(p.method(), "KEY")
+}
+
+func (p *Partition) sameMethod(other *Partition) bool {
+	return p.method() == other.method() && stripBrackets(normalizeSQLExpr(p.Expr)) == stripBrackets(normalizeSQLExpr(other.Expr))
+}
+
+func (p *Partition) Definition(name string) *PartitionDef {
+	for _, def := range p.Definitions {
+		if def.Name == name {
+			return def
+		}
+	}
+	return nil
+}
+
+// sqlDefinition returns the PARTITION BY clause, rotated partitions are generated for the given time
+func (p *Partition) sqlDefinition(now time.Time) string {
+	sql := "PARTITION BY " + p.method() + " (" + p.Expr + ")"
+	if p.isHash() {
+		if p.Count > 0 {
+			sql += " PARTITIONS " + strconv.Itoa(p.Count)
+		}
+		return sql
+	}
+	defs := append([]*PartitionDef{}, p.Definitions...)
+	if p.Rotation != nil {
+		// The first rotated partition also holds all the rows before it, so existing data fits in
+		defs = append(defs, p.Rotation.definitions(p.Rotation.periodStart(now, -1), p.Rotation.periodStart(now, p.Rotation.Ahead))...)
+	}
+	sql += " ("
+	for _, def := range defs {
+		sql += "PARTITION `" + def.Name + "` VALUES " + def.Values + ","
+	}
+	return sql[:len(sql)-1] + ")"
+}
+
+func (r *PartitionRotation) location() *time.Location {
+	if r.Location == nil {
+		return time.Local
+	}
+	return r.Location
+}
+
+func (r *PartitionRotation) nameFormat() string {
+	switch r.Period {
+	case PartitionMonthly:
+		return "200601"
+	case PartitionYearly:
+		return "2006"
+	default:
+		return "20060102"
+	}
+}
+
+// periodStart returns the start of the period which is n periods after the one contains t
+func (r *PartitionRotation) periodStart(t time.Time, n int) time.Time {
+	t = t.In(r.location())
+	y, m, d := t.Date()
+	switch r.Period {
+	case PartitionWeekly:
+		d -= (int(t.Weekday()) + 6) % 7 // weeks start on Monday
+		return time.Date(y, m, d+n*7, 0, 0, 0, 0, r.location())
+	case PartitionMonthly:
+		return time.Date(y, m+time.Month(n), 1, 0, 0, 0, 0, r.location())
+	case PartitionYearly:
+		return time.Date(y+n, 1, 1, 0, 0, 0, 0, r.location())
+	default:
+		return time.Date(y, m, d+n, 0, 0, 0, 0, r.location())
+	}
+}
+
+// partitionStart parses the start of the period from a partition name
+func (r *PartitionRotation) partitionStart(name string) (time.Time, bool) {
+	if len(name) < 2 || name[0] != 'p' {
+		return time.Time{}, false
+	}
+	t, e := time.ParseInLocation(r.nameFormat(), name[1:], r.location())
+	if e != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// definitions returns the partitions of the periods in [from, to]
+func (r *PartitionRotation) definitions(from, to time.Time) []*PartitionDef {
+	defs := make([]*PartitionDef, 0)
+	for t := from; !t.After(to); t = r.periodStart(t, 1) {
+		next := r.periodStart(t, 1)
+		bound := "'" + next.Format("2006-01-02 15:04:05") + "'"
+		if r.Bound != nil {
+			bound = r.Bound(next)
+		}
+		defs = append(defs, &PartitionDef{Name: "p" + t.Format(r.nameFormat()), Values: "LESS THAN (" + bound + ")"})
+	}
+	return defs
+}
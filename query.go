@@ -0,0 +1,357 @@
+package mysql
+
+import (
+	"reflect"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// Cond is a condition of the WHERE clause, the column names are checked against the schema when the query is run.
+// It could be passed as the where argument of Select, Count, Delete, etc., in place of a plain string, e.g.
+//
+//	sc.Select(ctx, And(Eq("status", 1), Or(Like("name", "a%"), IsNull("name"))))
+type Cond struct {
+	op       string // =, <>, >, >=, <, <=, IN, NOT IN, BETWEEN, LIKE, NOT LIKE, IS NULL, IS NOT NULL, AND, OR, NOT, RAW
+	column   string
+	values   []any
+	children []Cond
+	raw      string
+}
+
+func Eq(column string, value any) Cond  { return Cond{op: "=", column: column, values: []any{value}} }
+func Ne(column string, value any) Cond  { return Cond{op: "<>", column: column, values: []any{value}} }
+func Gt(column string, value any) Cond  { return Cond{op: ">", column: column, values: []any{value}} }
+func Gte(column string, value any) Cond { return Cond{op: ">=", column: column, values: []any{value}} }
+func Lt(column string, value any) Cond  { return Cond{op: "<", column: column, values: []any{value}} }
+func Lte(column string, value any) Cond { return Cond{op: "<=", column: column, values: []any{value}} }
+
+// In matches the column against the values, a slice or array is expanded into the placeholders,
+// an empty list matches nothing.
+func In(column string, values ...any) Cond {
+	return Cond{op: "IN", column: column, values: expandValues(values)}
+}
+
+// NotIn is the negation of In, an empty list matches everything
+func NotIn(column string, values ...any) Cond {
+	return Cond{op: "NOT IN", column: column, values: expandValues(values)}
+}
+
+func Between(column string, from, to any) Cond {
+	return Cond{op: "BETWEEN", column: column, values: []any{from, to}}
+}
+
+func Like(column string, pattern string) Cond {
+	return Cond{op: "LIKE", column: column, values: []any{pattern}}
+}
+
+func NotLike(column string, pattern string) Cond {
+	return Cond{op: "NOT LIKE", column: column, values: []any{pattern}}
+}
+
+func IsNull(column string) Cond    { return Cond{op: "IS NULL", column: column} }
+func IsNotNull(column string) Cond { return Cond{op: "IS NOT NULL", column: column} }
+
+// And joins the conditions by AND, it matches everything if empty
+func And(conds ...Cond) Cond { return Cond{op: "AND", children: conds} }
+
+// Or joins the conditions by OR, it matches nothing if empty
+func Or(conds ...Cond) Cond { return Cond{op: "OR", children: conds} }
+
+func Not(cond Cond) Cond { return Cond{op: "NOT", children: []Cond{cond}} }
+
+// Raw is a SQL fragment with its arguments, the column names in it are not checked
+func Raw(sql string, args ...any) Cond { return Cond{op: "RAW", raw: sql, values: args} }
+
+func expandValues(values []any) []any {
+	if len(values) != 1 {
+		return values
+	}
+	rv := reflect.ValueOf(values[0])
+	if (rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array) || rv.Type().Elem().Kind() == reflect.Uint8 {
+		return values // []byte is a single value
+	}
+	expanded := make([]any, rv.Len())
+	for i := range expanded {
+		expanded[i] = rv.Index(i).Interface()
+	}
+	return expanded
+}
+
+// build renders the condition with placeholders, "" is returned if it matches everything
+func (c Cond) build(fields map[string]*Field) (string, []any, error) {
+	switch c.op {
+	case "":
+		return "", nil, nil
+	case "RAW":
+		return c.raw, c.values, nil
+	case "AND", "OR":
+		sql := ""
+		args := make([]any, 0)
+		for _, child := range c.children {
+			s, a, e := child.build(fields)
+			if e != nil {
+				return "", nil, e
+			}
+			if s == "" {
+				if c.op == "OR" {
+					return "", nil, nil // OR TRUE
+				}
+				continue
+			}
+			if sql != "" {
+				sql += " " + c.op + " "
+			}
+			sql += "(" + s + ")"
+			args = append(args, a...)
+		}
+		if sql == "" && c.op == "OR" && len(c.children) == 0 {
+			return "1 = 0", nil, nil
+		}
+		return sql, args, nil
+	case "NOT":
+		s, a, e := c.children[0].build(fields)
+		if e != nil {
+			return "", nil, e
+		}
+		if s == "" {
+			return "1 = 0", nil, nil
+		}
+		return "NOT (" + s + ")", a, nil
+	}
+
+	field, ok := fields[c.column]
+	if !ok {
+		return "", nil, errors.New("Unknown column: " + c.column)
+	}
+	if c.op == "=" {
+		return fieldEqCondition(field, c.values[0])
+	}
+	if field.Encrypted && c.op != "IS NULL" && c.op != "IS NOT NULL" {
+		return "", nil, errors.New("Encrypted column could only be compared by Eq: " + c.column)
+	}
+	column := "`" + c.column + "`"
+	values := c.values
+	if c.op != "LIKE" && c.op != "NOT LIKE" { // patterns match the stored text
+		values = make([]any, len(c.values))
+		for i, v := range c.values {
+			var e error
+			if values[i], e = encodeFieldArg(field, v); e != nil {
+				return "", nil, e
+			}
+		}
+	}
+	switch c.op {
+	case "IS NULL", "IS NOT NULL":
+		return column + " " + c.op, nil, nil
+	case "BETWEEN":
+		return column + " BETWEEN ? AND ?", values, nil
+	case "IN", "NOT IN":
+		if len(c.values) == 0 {
+			if c.op == "IN" {
+				return "1 = 0", nil, nil
+			}
+			return "", nil, nil
+		}
+		marks := ""
+		for range c.values {
+			marks += "?,"
+		}
+		return column + " " + c.op + " (" + marks[:len(marks)-1] + ")", values, nil
+	default:
+		return column + " " + c.op + " ?", values, nil
+	}
+}
+
+// Query is a condition together with GROUP BY, HAVING, ORDER BY, LIMIT and OFFSET, e.g.
+//
+//	sc.Select(ctx, Where(Gt("id", 100), In("status", statuses)).OrderByDesc("id").Limit(20))
+//
+// GROUP BY, HAVING and ORDER BY could use the aliases of the expressions of the entity, see GetEntity.
+type Query struct {
+	cond   Cond
+	groups []string
+	having Cond
+	orders []queryOrder
+	limit  int64
+	offset int64
+}
+
+type queryOrder struct {
+	column string
+	desc   bool
+}
+
+// Where starts a query with the conditions joined by AND
+func Where(conds ...Cond) *Query {
+	return &Query{cond: And(conds...), limit: -1}
+}
+
+func (q *Query) GroupBy(columns ...string) *Query {
+	q.groups = append(q.groups, columns...)
+	return q
+}
+
+// Having adds the conditions on the groups joined by AND, it requires GroupBy.
+// The aliases of the expressions of an entity are only known by the methods of the entity, not by Schema.Count.
+func (q *Query) Having(conds ...Cond) *Query {
+	if q.having.op != "" {
+		conds = append([]Cond{q.having}, conds...)
+	}
+	q.having = And(conds...)
+	return q
+}
+
+func (q *Query) OrderBy(columns ...string) *Query {
+	for _, column := range columns {
+		q.orders = append(q.orders, queryOrder{column: column})
+	}
+	return q
+}
+
+func (q *Query) OrderByDesc(columns ...string) *Query {
+	for _, column := range columns {
+		q.orders = append(q.orders, queryOrder{column: column, desc: true})
+	}
+	return q
+}
+
+func (q *Query) Limit(n int64) *Query {
+	q.limit = n
+	return q
+}
+
+func (q *Query) Offset(n int64) *Query {
+	q.offset = n
+	return q
+}
+
+// buildWhere renders the where argument of a query method, a string with args, a Cond or a *Query,
+// into the WHERE / GROUP BY / HAVING clause and the ORDER BY / LIMIT clause, both are "" or start with a space.
+// The aliases of the expressions of an entity could be used by GROUP BY, HAVING and ORDER BY.
+func buildWhere(where any, args []any, fields map[string]*Field, aliases map[string]*Field) (string, string, []any, error) {
+	var cond Cond
+	var q *Query
+	switch w := where.(type) {
+	case nil:
+	case string:
+		if w == "" {
+			return "", "", args, nil
+		}
+		return " WHERE " + w, "", args, nil
+	case Cond:
+		cond = w
+	case *Query:
+		if w != nil {
+			q = w
+			cond = w.cond
+		}
+	default:
+		return "", "", nil, errors.New("Invalid where: " + reflect.TypeOf(where).String())
+	}
+	if len(args) > 0 {
+		return "", "", nil, errors.New("Arguments could only be used with a where string")
+	}
+
+	sql, args, e := cond.build(fields)
+	if e != nil {
+		return "", "", nil, e
+	}
+	if sql != "" {
+		sql = " WHERE " + sql
+	}
+	tail := ""
+	if q != nil {
+		if len(q.groups) == 0 && q.having.op != "" {
+			return "", "", nil, errors.New("HAVING could not be used without GROUP BY")
+		}
+		grouped := withAliases(fields, aliases)
+		for i, column := range q.groups {
+			if _, ok := grouped[column]; !ok {
+				return "", "", nil, errors.New("Unknown column: " + column)
+			}
+			if i == 0 {
+				sql += " GROUP BY "
+			} else {
+				sql += ","
+			}
+			sql += "`" + column + "`"
+		}
+		having, hargs, e := q.having.build(grouped)
+		if e != nil {
+			return "", "", nil, e
+		}
+		if having != "" {
+			sql += " HAVING " + having
+			args = append(args, hargs...)
+		}
+		for i, order := range q.orders {
+			if _, ok := grouped[order.column]; !ok {
+				return "", "", nil, errors.New("Unknown column: " + order.column)
+			}
+			if i == 0 {
+				tail += " ORDER BY "
+			} else {
+				tail += ","
+			}
+			tail += "`" + order.column + "`"
+			if order.desc {
+				tail += " DESC"
+			}
+		}
+		if q.limit >= 0 {
+			tail += " LIMIT " + strconv.FormatInt(q.limit, 10)
+		}
+		if q.offset > 0 {
+			if q.limit < 0 {
+				tail += " LIMIT 18446744073709551615" // MySQL requires LIMIT with OFFSET
+			}
+			tail += " OFFSET " + strconv.FormatInt(q.offset, 10)
+		}
+	}
+	return sql, tail, args, nil
+}
+
+// withAliases returns the fields together with the aliases of the expressions, the aliases take precedence
+func withAliases(fields map[string]*Field, aliases map[string]*Field) map[string]*Field {
+	if len(aliases) == 0 {
+		return fields
+	}
+	merged := make(map[string]*Field, len(fields)+len(aliases))
+	for name, field := range fields {
+		merged[name] = field
+	}
+	for name, field := range aliases {
+		merged[name] = field
+	}
+	return merged
+}
+
+// isGrouped reports whether the where argument of a query method has GROUP BY
+func isGrouped(where any) bool {
+	q, ok := where.(*Query)
+	return ok && q != nil && len(q.groups) > 0
+}
+
+// andWhere adds the condition to the where argument of a query method
+func andWhere(where any, args []any, cond Cond) (any, error) {
+	switch w := where.(type) {
+	case nil:
+		return cond, nil
+	case string:
+		if w == "" {
+			return cond, nil
+		}
+		return And(cond, Raw(w, args...)), nil
+	case Cond:
+		return And(cond, w), nil
+	case *Query:
+		if w == nil {
+			return cond, nil
+		}
+		q := *w
+		q.cond = And(cond, w.cond)
+		return &q, nil
+	}
+	return nil, errors.New("Invalid where: " + reflect.TypeOf(where).String())
+}
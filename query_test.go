@@ -0,0 +1,133 @@
+package mysql
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestCondBuild(t *testing.T) {
+	fields := map[string]*Field{
+		"id":     {Name: "id"},
+		"status": {Name: "status"},
+	}
+	cases := []struct {
+		name string
+		cond Cond
+		sql  string
+		args []any
+	}{
+		{name: "zero", cond: Cond{}, sql: ""},
+		{name: "empty and", cond: And(), sql: ""},
+		{name: "empty or", cond: Or(), sql: "1 = 0"},
+		{name: "empty in", cond: In("id"), sql: "1 = 0"},
+		{name: "empty slice in", cond: In("id", []int{}), sql: "1 = 0"},
+		{name: "empty not in", cond: NotIn("id"), sql: ""},
+		{name: "not empty and", cond: Not(And()), sql: "1 = 0"},
+		{name: "not empty or", cond: Not(Or()), sql: "NOT (1 = 0)"},
+		{name: "and skips empty", cond: And(And(), Eq("id", 1)), sql: "(`id` = ?)", args: []any{1}},
+		{name: "or with empty and", cond: Or(Eq("id", 1), And()), sql: ""},
+		{name: "or with empty in", cond: Or(In("id"), Eq("status", 2)), sql: "(1 = 0) OR (`status` = ?)", args: []any{2}},
+		{name: "expanded in", cond: In("id", []int{1, 2}), sql: "`id` IN (?,?)", args: []any{1, 2}},
+		{name: "eq nil", cond: Eq("id", nil), sql: "`id` IS NULL"},
+		{name: "nested", cond: And(Gt("id", 1), Not(Eq("status", 0))), sql: "(`id` > ?) AND (NOT (`status` = ?))", args: []any{1, 0}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sql, args, e := c.cond.build(fields)
+			if e != nil {
+				t.Fatal(e)
+			}
+			if sql != c.sql {
+				t.Errorf("got %q, want %q", sql, c.sql)
+			}
+			if len(args) != 0 || len(c.args) != 0 {
+				if !reflect.DeepEqual(args, c.args) {
+					t.Errorf("got args %v, want %v", args, c.args)
+				}
+			}
+		})
+	}
+
+	if _, _, e := Eq("unknown", 1).build(fields); e == nil {
+		t.Error("unknown column accepted")
+	}
+}
+
+func TestBuildWhere(t *testing.T) {
+	fields := map[string]*Field{"id": {Name: "id"}}
+	cases := []struct {
+		name  string
+		where any
+		cond  string
+		tail  string
+	}{
+		{name: "nil", where: nil},
+		{name: "nil query", where: (*Query)(nil)},
+		{name: "empty and", where: And()},
+		{name: "empty or", where: Or(), cond: " WHERE 1 = 0"},
+		{name: "empty in", where: Where(In("id")), cond: " WHERE (1 = 0)"},
+		{name: "empty where", where: Where().OrderByDesc("id").Limit(10), tail: " ORDER BY `id` DESC LIMIT 10"},
+		{name: "offset without limit", where: Where(Not(Or())).Offset(5), cond: " WHERE (NOT (1 = 0))", tail: " LIMIT 18446744073709551615 OFFSET 5"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cond, tail, _, e := buildWhere(c.where, nil, fields, nil)
+			if e != nil {
+				t.Fatal(e)
+			}
+			if cond != c.cond || tail != c.tail {
+				t.Errorf("got %q %q, want %q %q", cond, tail, c.cond, c.tail)
+			}
+		})
+	}
+
+	if _, _, _, e := buildWhere(Eq("id", 1), []any{1}, fields, nil); e == nil {
+		t.Error("arguments accepted with a Cond")
+	}
+	if _, _, _, e := buildWhere(Where().Having(Gt("id", 1)), nil, fields, nil); e == nil {
+		t.Error("HAVING accepted without GROUP BY")
+	}
+}
+
+func TestCondBlindIndex(t *testing.T) {
+	SetKeyProvider(&StaticKeyProvider{
+		Keys:     map[string][]byte{"k": bytes.Repeat([]byte{1}, 32)},
+		Current:  "k",
+		BlindKey: []byte("blind key"),
+	})
+	defer SetKeyProvider(nil)
+	email := &Field{Name: "email", Encrypted: true, BlindIndex: "email_bidx"}
+	fields := map[string]*Field{
+		"email": email,
+		"phone": {Name: "phone", Encrypted: true},
+	}
+
+	sql, args, e := Eq("email", "a@example.com").build(fields)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if sql != "`email_bidx` = ?" {
+		t.Errorf("got %q, want the blind index column", sql)
+	}
+	want, e := blindIndexValue(email, reflect.ValueOf("a@example.com"))
+	if e != nil {
+		t.Fatal(e)
+	}
+	if len(args) != 1 || !bytes.Equal(args[0].([]byte), want) {
+		t.Errorf("got args %v, want the blind index %x", args, want)
+	}
+	other, _ := blindIndexValue(email, reflect.ValueOf("b@example.com"))
+	if bytes.Equal(other, want) {
+		t.Error("different values give the same blind index")
+	}
+
+	if sql, _, _ := Eq("email", nil).build(fields); sql != "`email_bidx` IS NULL" {
+		t.Errorf("got %q, want the blind index column IS NULL", sql)
+	}
+	for _, cond := range []Cond{Eq("phone", "123"), Ne("email", "a@example.com"), In("email", "a@example.com")} {
+		if _, _, e := cond.build(fields); e == nil {
+			t.Errorf("%s %s accepted on an encrypted column", cond.column, cond.op)
+		}
+	}
+}
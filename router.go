@@ -0,0 +1,277 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// DBShard is a pair of read and write databases holding a shard
+type DBShard struct {
+	Read  *DB
+	Write *DB
+}
+
+// ShardStrategy maps a shard key to the index of a shard in [0, shards)
+type ShardStrategy interface {
+	ShardIndex(key any, shards int) int
+}
+
+// ModuloStrategy maps integer keys by key % shards, and other keys by the FNV-1a hash of their string form
+type ModuloStrategy struct{}
+
+func (ModuloStrategy) ShardIndex(key any, shards int) int {
+	rv := reflect.ValueOf(key)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n := rv.Int() % int64(shards)
+		if n < 0 {
+			n += int64(shards)
+		}
+		return int(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int(rv.Uint() % uint64(shards))
+	}
+	return int(hashKey(key) % uint64(shards))
+}
+
+// ConsistentHashStrategy maps keys onto a hash ring with virtual nodes,
+// so only about 1/N of the keys move when a shard is appended.
+type ConsistentHashStrategy struct {
+	Replicas int // Virtual nodes per shard, 128 if 0
+
+	mu     sync.Mutex
+	shards int
+	ring   []uint64
+	nodes  map[uint64]int
+}
+
+func (s *ConsistentHashStrategy) ShardIndex(key any, shards int) int {
+	s.mu.Lock()
+	if s.shards != shards {
+		s.build(shards)
+	}
+	ring, nodes := s.ring, s.nodes
+	s.mu.Unlock()
+
+	h := hashKey(key)
+	i := sort.Search(len(ring), func(i int) bool { return ring[i] >= h })
+	if i == len(ring) {
+		i = 0
+	}
+	return nodes[ring[i]]
+}
+
+func (s *ConsistentHashStrategy) build(shards int) {
+	replicas := s.Replicas
+	if replicas <= 0 {
+		replicas = 128
+	}
+	s.shards = shards
+	s.ring = make([]uint64, 0, shards*replicas)
+	s.nodes = make(map[uint64]int, shards*replicas)
+	for i := 0; i < shards; i++ {
+		for j := 0; j < replicas; j++ {
+			h := hashKey(strconv.Itoa(i) + "#" + strconv.Itoa(j))
+			if _, ok := s.nodes[h]; ok {
+				continue
+			}
+			s.nodes[h] = i
+			s.ring = append(s.ring, h)
+		}
+	}
+	sort.Slice(s.ring, func(i, j int) bool { return s.ring[i] < s.ring[j] })
+}
+
+// LookupStrategy maps keys by a lookup table, e.g. loaded from a directory service,
+// the keys not found are mapped by Fallback, or ModuloStrategy if nil.
+type LookupStrategy struct {
+	Lookup   func(key any) (int, bool)
+	Fallback ShardStrategy
+}
+
+func (s *LookupStrategy) ShardIndex(key any, shards int) int {
+	if i, ok := s.Lookup(key); ok && i >= 0 && i < shards {
+		return i
+	}
+	if s.Fallback != nil {
+		return s.Fallback.ShardIndex(key, shards)
+	}
+	return ModuloStrategy{}.ShardIndex(key, shards)
+}
+
+func hashKey(key any) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(fmt.Sprint(key)))
+	return h.Sum64()
+}
+
+// ShardRouter spreads the records of T over the tables with the same name in several databases by a shard key column.
+// Each shard is a Schema[T] created and migrated as NewSchema does.
+type ShardRouter[T interface{}] struct {
+	Name     string
+	Column   string
+	Strategy ShardStrategy
+	Shards   []*Schema[T]
+
+	keyField *Field
+}
+
+// NewShardRouter opens the table in each of the databases, strategy is ModuloStrategy if nil
+func NewShardRouter[T interface{}](ctx context.Context, dbs []DBShard, name string, column string, strategy ShardStrategy) (*ShardRouter[T], error) {
+	if len(dbs) == 0 {
+		return nil, errors.New("No shard for " + name)
+	}
+	if strategy == nil {
+		strategy = ModuloStrategy{}
+	}
+	router := &ShardRouter[T]{
+		Name:     name,
+		Column:   column,
+		Strategy: strategy,
+		Shards:   make([]*Schema[T], 0, len(dbs)),
+	}
+	for i, db := range dbs {
+		sc, e := OpenSchema[T](db.Read, db.Write, name, WithContext(ctx))
+		if e != nil {
+			return nil, errors.Wrap(e, "Open shard "+strconv.Itoa(i)+" failed")
+		}
+		router.Shards = append(router.Shards, sc)
+		if i > 0 {
+			continue
+		}
+		router.keyField = sc.FieldsByColumn[column]
+		if router.keyField == nil {
+			return nil, errors.New("Unknown shard column: " + column)
+		}
+		if router.keyField.IsAutoIncrement || router.keyField.IsNullable {
+			// the key is not known before the insert
+			return nil, errors.New("Auto increment or nullable column could not be the shard key: " + column)
+		}
+	}
+	return router, nil
+}
+
+// Shard returns the schema of the shard holding the key
+func (r *ShardRouter[T]) Shard(key any) *Schema[T] {
+	return r.Shards[r.Strategy.ShardIndex(key, len(r.Shards))]
+}
+
+// ShardOf returns the schema of the shard holding data, by the value of the shard key column
+func (r *ShardRouter[T]) ShardOf(data *T) (*Schema[T], error) {
+	v := fieldValueByIndex(reflect.ValueOf(data).Elem(), r.keyField.EntityIndex)
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, errors.New("Nil shard key: " + r.Column)
+		}
+		v = v.Elem()
+	}
+	return r.Shard(v.Interface()), nil
+}
+
+func (r *ShardRouter[T]) Insert(ctx context.Context, data *T) error {
+	sc, e := r.ShardOf(data)
+	if e != nil {
+		return e
+	}
+	return sc.Insert(ctx, data)
+}
+
+func (r *ShardRouter[T]) Update(ctx context.Context, data *T, columns ...string) (int64, error) {
+	for _, column := range columns {
+		if column == r.Column {
+			return 0, errors.New("Cannot update shard key: " + column)
+		}
+	}
+	sc, e := r.ShardOf(data)
+	if e != nil {
+		return 0, e
+	}
+	return sc.Update(ctx, data, columns...)
+}
+
+// SelectByKey selects from the shard holding the key
+func (r *ShardRouter[T]) SelectByKey(ctx context.Context, key any, where any, args ...any) ([]*T, error) {
+	return r.Shard(key).Select(ctx, where, args...)
+}
+
+func (r *ShardRouter[T]) SelectOneByKey(ctx context.Context, key any, where any, args ...any) (*T, error) {
+	return r.Shard(key).SelectOne(ctx, where, args...)
+}
+
+func (r *ShardRouter[T]) DeleteByKey(ctx context.Context, key any, where any, args ...any) (int64, error) {
+	return r.Shard(key).Delete(ctx, where, args...)
+}
+
+func (r *ShardRouter[T]) CountByKey(ctx context.Context, key any, where any, args ...any) (int64, error) {
+	return r.Shard(key).Count(ctx, where, args...)
+}
+
+// Select queries all the shards concurrently and merges the results, in the order of the shards
+// unless where is a *Query with ORDER BY. The LIMIT and OFFSET of a *Query apply to the merged rows,
+// GROUP BY is not supported, see mergeRows.
+func (r *ShardRouter[T]) Select(ctx context.Context, where any, args ...any) ([]*T, error) {
+	shardWhere, e := scatterWhere(where)
+	if e != nil {
+		return nil, e
+	}
+	results := make([][]*T, len(r.Shards))
+	e = r.scatter(func(i int, sc *Schema[T]) error {
+		rows, e := sc.Select(ctx, shardWhere, args...)
+		results[i] = rows
+		return e
+	})
+	if e != nil {
+		return nil, e
+	}
+	if len(r.Shards) == 0 {
+		return make([]*T, 0), nil
+	}
+	return mergeRows(results, where, r.Shards[0].FieldsByColumn)
+}
+
+// Count counts on all the shards concurrently, GROUP BY is not supported since a group could span the shards
+func (r *ShardRouter[T]) Count(ctx context.Context, where any, args ...any) (int64, error) {
+	if isGrouped(where) {
+		return 0, errors.New("GROUP BY could not be used across shards")
+	}
+	counts := make([]int64, len(r.Shards))
+	e := r.scatter(func(i int, sc *Schema[T]) error {
+		n, e := sc.Count(ctx, where, args...)
+		counts[i] = n
+		return e
+	})
+	if e != nil {
+		return 0, e
+	}
+	var total int64
+	for _, n := range counts {
+		total += n
+	}
+	return total, nil
+}
+
+func (r *ShardRouter[T]) scatter(f func(i int, sc *Schema[T]) error) error {
+	errs := make([]error, len(r.Shards))
+	wg := sync.WaitGroup{}
+	for i, sc := range r.Shards {
+		wg.Add(1)
+		go func(i int, sc *Schema[T]) {
+			defer wg.Done()
+			errs[i] = f(i, sc)
+		}(i, sc)
+	}
+	wg.Wait()
+	for i, e := range errs {
+		if e != nil {
+			return errors.Wrap(e, "Shard "+strconv.Itoa(i)+" failed")
+		}
+	}
+	return nil
+}
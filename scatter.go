@@ -0,0 +1,210 @@
+package mysql
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// scatterWhere returns the where argument run on each table of a scatter select, the rows up to the LIMIT and
+// OFFSET are selected from every table, the order and the limit are applied again to the merged rows by mergeRows.
+// GROUP BY is rejected, the groups spanning the tables could not be merged.
+func scatterWhere(where any) (any, error) {
+	q, ok := where.(*Query)
+	if !ok || q == nil {
+		return where, nil
+	}
+	if len(q.groups) > 0 {
+		return nil, errors.New("GROUP BY could not be used across shards")
+	}
+	if q.offset == 0 {
+		return where, nil
+	}
+	w := *q
+	if w.limit >= 0 {
+		w.limit += w.offset
+	}
+	w.offset = 0
+	return &w, nil
+}
+
+// mergeRows merges the rows selected from the tables by scatterWhere, sorts them by the ORDER BY of where
+// and applies its LIMIT and OFFSET. Values are compared in their stored form, strings are compared bytewise
+// instead of by the collation of the column, NULL comes first as in MySQL.
+func mergeRows[T interface{}](results [][]*T, where any, fields map[string]*Field) ([]*T, error) {
+	merged := make([]*T, 0)
+	for _, rows := range results {
+		merged = append(merged, rows...)
+	}
+	q, ok := where.(*Query)
+	if !ok || q == nil {
+		return merged, nil
+	}
+
+	if len(q.orders) > 0 {
+		keys := make([][]any, len(merged))
+		for i, row := range merged {
+			val := reflect.ValueOf(row).Elem()
+			keys[i] = make([]any, len(q.orders))
+			for j, order := range q.orders {
+				field, ok := fields[order.column]
+				if !ok {
+					return nil, errors.New("Unknown column: " + order.column)
+				}
+				v, e := orderValue(field, fieldValueByIndex(val, field.EntityIndex))
+				if e != nil {
+					return nil, e
+				}
+				keys[i][j] = v
+			}
+		}
+		index := make([]int, len(merged))
+		for i := range index {
+			index[i] = i
+		}
+		sort.SliceStable(index, func(a, b int) bool {
+			for j, order := range q.orders {
+				c := compareValues(keys[index[a]][j], keys[index[b]][j])
+				if order.desc {
+					c = -c
+				}
+				if c != 0 {
+					return c < 0
+				}
+			}
+			return false
+		})
+		sorted := make([]*T, len(merged))
+		for i, x := range index {
+			sorted[i] = merged[x]
+		}
+		merged = sorted
+	}
+
+	if q.offset > 0 {
+		if q.offset >= int64(len(merged)) {
+			return make([]*T, 0), nil
+		}
+		merged = merged[q.offset:]
+	}
+	if q.limit >= 0 && q.limit < int64(len(merged)) {
+		merged = merged[:q.limit]
+	}
+	return merged, nil
+}
+
+// orderValue returns the value of the field as stored in the column: nil, int64, uint64, float64, bool,
+// string, []byte or time.Time
+func orderValue(field *Field, v reflect.Value) (any, error) {
+	arg, e := encodeFieldArg(field, v.Interface())
+	if e != nil {
+		return nil, e
+	}
+	if valuer, ok := arg.(driver.Valuer); ok {
+		if rv := reflect.ValueOf(arg); rv.Kind() == reflect.Ptr && rv.IsNil() {
+			return nil, nil
+		}
+		if arg, e = valuer.Value(); e != nil {
+			return nil, errors.Wrap(e, "Value of "+field.Name+" failed")
+		}
+	}
+	rv := reflect.ValueOf(arg)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil, nil
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return nil, nil
+	}
+	if t, ok := rv.Interface().(time.Time); ok {
+		return t, nil
+	}
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return rv.Uint(), nil
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), nil
+	case reflect.Bool:
+		return rv.Bool(), nil
+	case reflect.String:
+		return rv.String(), nil
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return rv.Bytes(), nil
+		}
+	}
+	return nil, errors.New("Column " + field.Name + " could not be ordered across shards")
+}
+
+// compareValues compares the values returned by orderValue, nil is less than the others
+func compareValues(a, b any) int {
+	if a == nil || b == nil {
+		switch {
+		case a == nil && b == nil:
+			return 0
+		case a == nil:
+			return -1
+		}
+		return 1
+	}
+	switch x := a.(type) {
+	case int64:
+		switch y := b.(type) {
+		case int64:
+			return compareOrdered(x, y)
+		case uint64:
+			if x < 0 {
+				return -1
+			}
+			return compareOrdered(uint64(x), y)
+		}
+	case uint64:
+		if y, ok := b.(uint64); ok {
+			return compareOrdered(x, y)
+		}
+		return -compareValues(b, a)
+	case float64:
+		if y, ok := b.(float64); ok {
+			return compareOrdered(x, y)
+		}
+	case bool:
+		if y, ok := b.(bool); ok && x != y {
+			if x {
+				return 1
+			}
+			return -1
+		}
+	case string:
+		if y, ok := b.(string); ok {
+			return strings.Compare(x, y)
+		}
+	case []byte:
+		if y, ok := b.([]byte); ok {
+			return bytes.Compare(x, y)
+		}
+	case time.Time:
+		if y, ok := b.(time.Time); ok {
+			return x.Compare(y)
+		}
+	}
+	return 0
+}
+
+func compareOrdered[V int64 | uint64 | float64](x, y V) int {
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
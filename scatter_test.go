@@ -0,0 +1,68 @@
+package mysql
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMergeRows(t *testing.T) {
+	type row struct {
+		ID      int64     `db:"id pk"`
+		Score   *float64  `db:"score"`
+		Created time.Time `db:"created"`
+	}
+	sc := &Schema[row]{Name: "scores"}
+	if e := sc.fromType(reflect.TypeOf((*row)(nil))); e != nil {
+		t.Fatal(e)
+	}
+	score := func(v float64) *float64 { return &v }
+	day := func(d int) time.Time { return time.Date(2026, 10, d, 0, 0, 0, 0, time.UTC) }
+	shards := [][]*row{
+		{{ID: 1, Score: score(3), Created: day(3)}, {ID: 4, Score: nil, Created: day(1)}},
+		{{ID: 2, Score: score(5), Created: day(2)}, {ID: 3, Score: score(3), Created: day(4)}},
+		{},
+	}
+	cases := []struct {
+		name  string
+		where any
+		ids   []int64
+	}{
+		{name: "no query", where: Eq("id", 1), ids: []int64{1, 4, 2, 3}},
+		{name: "order", where: Where().OrderBy("id"), ids: []int64{1, 2, 3, 4}},
+		{name: "order desc with null last", where: Where().OrderByDesc("score").OrderBy("id"), ids: []int64{2, 1, 3, 4}},
+		{name: "null first", where: Where().OrderBy("score", "created"), ids: []int64{4, 1, 3, 2}},
+		{name: "time", where: Where().OrderByDesc("created"), ids: []int64{3, 1, 2, 4}},
+		{name: "limit", where: Where().OrderBy("id").Limit(2), ids: []int64{1, 2}},
+		{name: "limit and offset", where: Where().OrderBy("id").Limit(2).Offset(1), ids: []int64{2, 3}},
+		{name: "offset after the end", where: Where().OrderBy("id").Offset(10), ids: []int64{}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			rows, e := mergeRows(shards, c.where, sc.FieldsByColumn)
+			if e != nil {
+				t.Fatal(e)
+			}
+			ids := make([]int64, len(rows))
+			for i, r := range rows {
+				ids[i] = r.ID
+			}
+			if !reflect.DeepEqual(ids, c.ids) {
+				t.Errorf("got %v, want %v", ids, c.ids)
+			}
+		})
+	}
+}
+
+func TestScatterWhere(t *testing.T) {
+	w, e := scatterWhere(Where().OrderBy("id").Limit(10).Offset(20))
+	if e != nil {
+		t.Fatal(e)
+	}
+	if q := w.(*Query); q.limit != 30 || q.offset != 0 {
+		t.Errorf("got LIMIT %d OFFSET %d, want LIMIT 30 OFFSET 0", q.limit, q.offset)
+	}
+	if _, e := scatterWhere(Where().GroupBy("id")); e == nil {
+		t.Error("GROUP BY accepted")
+	}
+}
@@ -0,0 +1,187 @@
+package mysql
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ChunkOptions are the options of Schema.Chunk
+type ChunkOptions struct {
+	Size        int64         // Rows per chunk, 1000 by default
+	Concurrency int           // Chunks processed at the same time, 1 by default
+	Tx          bool          // Process each chunk in its own transaction on the write database
+	Throttle    time.Duration // Pause between the chunks to protect the replicas, optional
+	Resume      string        // Checkpoint to resume from, the beginning of the table if empty
+
+	// Checkpoint is called with the checkpoint after a chunk and all the chunks before it are processed,
+	// it could be saved and passed by Resume to continue an interrupted job. Optional.
+	// The checkpoints are signed cursors, so SetCursorKey is required if Checkpoint or Resume is used,
+	// the generated key would not verify a checkpoint saved before a restart.
+	Checkpoint func(ctx context.Context, checkpoint string) error
+}
+
+// Chunk walks the records matching where in the order of the primary key, composite keys included,
+// and calls fn with chunks of opts.Size records. Each chunk is selected by a keyset range from the read database,
+// db passed to fn is the transaction of the chunk if opts.Tx is set, or the write database otherwise.
+// The walk stops at the first error, which is returned; chunks being processed concurrently are finished.
+func (sc *Schema[T]) Chunk(ctx context.Context, opts *ChunkOptions, fn func(ctx context.Context, db IDBLike, chunk []*T) error, where any, args ...any) error {
+	if opts == nil {
+		opts = &ChunkOptions{}
+	}
+	size := opts.Size
+	if size < 1 {
+		size = 1000
+	}
+	concurrency := opts.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	if opts.Tx && sc.dbWrite == nil {
+		return ErrNotReady
+	}
+	if (opts.Checkpoint != nil || opts.Resume != "") && !hasCursorKey() {
+		return ErrNoCursorKey
+	}
+	if q, ok := where.(*Query); ok && q != nil && len(q.orders) > 0 {
+		return errors.New("ORDER BY could not be used with Chunk")
+	}
+	if len(sc.primaryFields) == 0 {
+		return ErrNoPrimaryKey
+	}
+	orders, _, e := sc.entity.cursorOrder(where)
+	if e != nil {
+		return e
+	}
+	keyFields, e := sc.entity.cursorFields(orders)
+	if e != nil {
+		return e
+	}
+	spec := orderSpec(orders, false)
+
+	parent := ctx
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var mu sync.Mutex
+	var failure error
+	fail := func(e error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if failure == nil {
+			failure = e
+			cancel()
+		}
+	}
+	// Checkpoints are reported in order, a chunk finished early waits for the chunks before it.
+	// The callback is called outside mu, cpMu keeps the calls in order and skips a checkpoint already passed.
+	finished := make(map[int]string)
+	reported := 0
+	var cpMu sync.Mutex
+	saved := 0
+	finish := func(seq int, checkpoint string) error {
+		mu.Lock()
+		finished[seq] = checkpoint
+		last := ""
+		for {
+			cp, ok := finished[reported]
+			if !ok {
+				break
+			}
+			delete(finished, reported)
+			reported++
+			last = cp
+		}
+		upTo, failed := reported, failure != nil
+		mu.Unlock()
+		if last == "" || opts.Checkpoint == nil || failed {
+			return nil
+		}
+
+		cpMu.Lock()
+		defer cpMu.Unlock()
+		if upTo <= saved {
+			return nil // a later checkpoint is saved
+		}
+		if e := opts.Checkpoint(ctx, last); e != nil {
+			return e
+		}
+		saved = upTo
+		return nil
+	}
+
+	sem := make(chan struct{}, concurrency)
+	wg := sync.WaitGroup{}
+	cursor := opts.Resume
+walk:
+	for seq := 0; ; seq++ {
+		if seq > 0 && opts.Throttle > 0 {
+			select {
+			case <-time.After(opts.Throttle):
+			case <-ctx.Done():
+				break walk
+			}
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			break walk
+		}
+		chunk, next, _, e := sc.entity.SelectCursorEx(ctx, sc.dbRead.Ctx, cursor, size, where, args...)
+		if e != nil {
+			<-sem
+			fail(errors.Wrap(e, "Chunk failed"))
+			break
+		}
+		if len(chunk) == 0 {
+			<-sem
+			break
+		}
+		checkpoint, e := sc.entity.cursorOf(spec, keyFields, chunk[len(chunk)-1], false)
+		if e != nil {
+			<-sem
+			fail(e)
+			break
+		}
+
+		wg.Add(1)
+		go func(seq int, chunk []*T, checkpoint string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if e := sc.processChunk(ctx, opts.Tx, fn, chunk); e != nil {
+				fail(e)
+				return
+			}
+			if e := finish(seq, checkpoint); e != nil {
+				fail(errors.Wrap(e, "Checkpoint failed"))
+			}
+		}(seq, chunk, checkpoint)
+
+		if next == "" {
+			break
+		}
+		cursor = checkpoint
+	}
+	wg.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if failure != nil {
+		return failure
+	}
+	return parent.Err()
+}
+
+func (sc *Schema[T]) processChunk(ctx context.Context, tx bool, fn func(ctx context.Context, db IDBLike, chunk []*T) error, chunk []*T) error {
+	if tx {
+		return sc.dbWrite.Tx(ctx, func(ctx context.Context, db IDBLike) error {
+			return fn(ctx, db, chunk)
+		})
+	}
+	if sc.dbWrite != nil {
+		return fn(ctx, sc.dbWrite.Ctx, chunk)
+	}
+	return fn(ctx, sc.dbRead.Ctx, chunk)
+}
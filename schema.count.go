@@ -7,14 +7,21 @@ import (
 )
 
 // Get record count from the database
-func (sc *Schema[T]) CountEx(ctx context.Context, db IDBLike, where string, args ...any) (int64, error) {
+func (sc *Schema[T]) CountEx(ctx context.Context, db IDBLike, where any, args ...any) (int64, error) {
 	if sc.dbRead == nil {
 		return 0, ErrNotReady
 	}
 
-	s := "SELECT COUNT(*) FROM `" + sc.Name + "`"
-	if where != "" {
-		s += " WHERE " + where
+	cond, _, args, e := buildWhere(where, args, sc.FieldsByColumn, nil)
+	if e != nil {
+		if isGrouped(where) {
+			return 0, errors.Wrap(e, "Count failed, the aliases of entity expressions are not known by Schema.Count")
+		}
+		return 0, e
+	}
+	s := "SELECT COUNT(*) FROM `" + sc.Name + "`" + cond
+	if isGrouped(where) {
+		s = "SELECT COUNT(*) FROM (SELECT 1 FROM `" + sc.Name + "`" + cond + ") `g`" // count of the groups
 	}
 
 	var count int64
@@ -25,6 +32,6 @@ func (sc *Schema[T]) CountEx(ctx context.Context, db IDBLike, where string, args
 	return count, nil
 }
 
-func (sc *Schema[T]) Count(ctx context.Context, where string, args ...any) (int64, error) {
+func (sc *Schema[T]) Count(ctx context.Context, where any, args ...any) (int64, error) {
 	return sc.CountEx(ctx, sc.dbRead.Ctx, where, args...)
 }
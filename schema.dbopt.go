@@ -3,7 +3,11 @@ package mysql
 import (
 	"context"
 	"database/sql"
+	"strconv"
+	"strings"
+	"time"
 
+	driver "github.com/go-sql-driver/mysql"
 	"github.com/pkg/errors"
 )
 
@@ -16,33 +20,65 @@ func (sc *Schema[T]) loadSchema(ctx context.Context) error {
 	sc.Fields = make([]*Field, 0)
 	sc.Indices = make([]*Index, 0)
 
-	if e := sc.dbWrite.Ctx.QueryRowContext(ctx, "SELECT `ENGINE`,`TABLE_COLLATION`,`TABLE_COMMENT` FROM `information_schema`.`TABLES` WHERE `TABLE_SCHEMA` = ? AND `TABLE_NAME` = ?", dbName, sc.Name).Scan(&sc.Engine, &sc.Collate, &sc.Comment); e != nil {
+	var engine, collate, charset, rowFormat, createOptions sql.NullString
+	var tableType string
+	if e := sc.dbWrite.Ctx.QueryRowContext(ctx, "SELECT `t`.`TABLE_TYPE`,`t`.`ENGINE`,`t`.`TABLE_COLLATION`,`t`.`TABLE_COMMENT`,`t`.`ROW_FORMAT`,`t`.`CREATE_OPTIONS`,`c`.`CHARACTER_SET_NAME` FROM `information_schema`.`TABLES` `t` LEFT JOIN `information_schema`.`COLLATIONS` `c` ON `c`.`COLLATION_NAME` = `t`.`TABLE_COLLATION` WHERE `t`.`TABLE_SCHEMA` = ? AND `t`.`TABLE_NAME` = ?", dbName, sc.Name).Scan(&tableType, &engine, &collate, &sc.Comment, &rowFormat, &createOptions, &charset); e != nil {
 		if e == sql.ErrNoRows {
 			return ErrNotFound
 		}
 		return errors.Wrap(e, "Get table info failed")
 	}
+	sc.isView = tableType == "VIEW" // views have no engine and collation
+	sc.Engine = engine.String
+	sc.Collate = collate.String
+	sc.Charset = charset.String
+	sc.RowFormat = rowFormat.String
+	for _, opt := range strings.Fields(createOptions.String) {
+		if k, v, ok := strings.Cut(opt, "="); ok {
+			switch strings.ToLower(k) {
+			case "row_format":
+				sc.RowFormat = v // the declared one takes precedence
+			case "compression":
+				sc.Compression = strings.Trim(v, "'\"")
+			}
+		}
+	}
 
-	rows, e := sc.dbWrite.Ctx.QueryContext(ctx, "SELECT `COLUMN_NAME`,`COLUMN_TYPE`,`IS_NULLABLE`,`COLUMN_DEFAULT`,`COLUMN_COMMENT`,`EXTRA` FROM `information_schema`.`COLUMNS` WHERE `TABLE_SCHEMA` = ? AND `TABLE_NAME` = ?", dbName, sc.Name)
+	rows, e := sc.dbWrite.Ctx.QueryContext(ctx, "SELECT `COLUMN_NAME`,`COLUMN_TYPE`,`IS_NULLABLE`,`COLUMN_DEFAULT`,`COLUMN_COMMENT`,`EXTRA`,`GENERATION_EXPRESSION`,`CHARACTER_SET_NAME`,`COLLATION_NAME` FROM `information_schema`.`COLUMNS` WHERE `TABLE_SCHEMA` = ? AND `TABLE_NAME` = ? ORDER BY `ORDINAL_POSITION`", dbName, sc.Name)
 	if e != nil {
 		return errors.Wrap(e, "Get table columns failed")
 	}
+	defer rows.Close()
 
 	for rows.Next() {
 		var field Field
 		var extra, isNullable string
-		var defaultValue sql.NullString
-		if e := rows.Scan(&field.Name, &field.Type, &isNullable, &defaultValue, &field.Comment, &extra); e != nil {
+		var defaultValue, genExpr, charset, collate sql.NullString
+		if e := rows.Scan(&field.Name, &field.Type, &isNullable, &defaultValue, &field.Comment, &extra, &genExpr, &charset, &collate); e != nil {
 			return errors.Wrap(e, "Scan table columns failed")
 		}
-		if extra == "auto_increment" {
+		extra = strings.ToLower(extra)
+		if strings.Contains(extra, "auto_increment") {
 			field.IsAutoIncrement = true
 		}
 		if isNullable == "YES" {
 			field.IsNullable = true
 		}
+		field.Charset = charset.String
+		field.Collate = collate.String
 		if defaultValue.Valid {
-			field.DefaultValue = defaultValue.String
+			field.DefaultValue = defaultValueFromSchema(defaultValue.String, strings.Contains(extra, "default_generated"))
+		}
+		if genExpr.Valid && genExpr.String != "" {
+			field.GeneratedExpr = genExpr.String
+			if strings.Contains(extra, "stored generated") {
+				field.GeneratedType = "STORED"
+			} else {
+				field.GeneratedType = "VIRTUAL"
+			}
+		}
+		if i := strings.Index(extra, "on update "); i >= 0 {
+			field.OnUpdate = strings.ToUpper(strings.TrimSpace(extra[i+len("on update "):]))
 		}
 		sc.Fields = append(sc.Fields, &field)
 	}
@@ -51,6 +87,7 @@ func (sc *Schema[T]) loadSchema(ctx context.Context) error {
 	if e != nil {
 		return errors.Wrap(e, "Get table indexs failed")
 	}
+	defer rows.Close()
 
 	idxMap := make(map[string]int)
 	for rows.Next() {
@@ -76,7 +113,63 @@ func (sc *Schema[T]) loadSchema(ctx context.Context) error {
 		}
 	}
 
-	return nil
+	sc.Checks = make([]*Check, 0)
+	rows, e = sc.dbWrite.Ctx.QueryContext(ctx, "SELECT `tc`.`CONSTRAINT_NAME`,`cc`.`CHECK_CLAUSE` FROM `information_schema`.`TABLE_CONSTRAINTS` `tc` JOIN `information_schema`.`CHECK_CONSTRAINTS` `cc` ON `cc`.`CONSTRAINT_SCHEMA` = `tc`.`CONSTRAINT_SCHEMA` AND `cc`.`CONSTRAINT_NAME` = `tc`.`CONSTRAINT_NAME` WHERE `tc`.`TABLE_SCHEMA` = ? AND `tc`.`TABLE_NAME` = ? AND `tc`.`CONSTRAINT_TYPE` = 'CHECK'", dbName, sc.Name)
+	if e != nil {
+		if mysqlErr, ok := e.(*driver.MySQLError); ok && mysqlErr.Number == 1109 {
+			rows = nil // CHECK_CONSTRAINTS is not available before MySQL 8.0.16
+		} else {
+			return errors.Wrap(e, "Get table checks failed")
+		}
+	} else {
+		defer rows.Close()
+	}
+
+	for rows != nil && rows.Next() {
+		var check Check
+		if e := rows.Scan(&check.Name, &check.Expr); e != nil {
+			return errors.Wrap(e, "Scan table checks failed")
+		}
+		sc.Checks = append(sc.Checks, &check)
+	}
+
+	return sc.loadPartition(ctx, dbName)
+}
+
+// isSerializedToScalar reports whether the column was the json mediumtext of a serialized field,
+// which is now mapped to a scalar type, i.e. pointer and sql.Null fields serialized by older versions
+func isSerializedToScalar(cur *Field, field *Field) bool {
+	if !field.nullType || baseType(cur.Type) != "mediumtext" || field.serializer() != nil || field.Encrypted {
+		return false
+	}
+	switch baseType(field.Type) {
+	case "mediumtext", "longtext", "json":
+		return false // still a text column of any length, varchar is refused as the json quotes would be kept
+	}
+	return true
+}
+
+// generatedState describes whether the column is generated, e.g. "stored generated" or "plain"
+func generatedState(field *Field) string {
+	if !field.IsGenerated() {
+		return "plain"
+	}
+	return strings.ToLower(field.GeneratedType) + " generated"
+}
+
+// defaultValueFromSchema converts COLUMN_DEFAULT of information_schema into SQL format.
+// Literals are reported without quotes, and expression defaults are reported without the surrounding brackets.
+func defaultValueFromSchema(v string, generated bool) string {
+	if v == "NULL" || currentTimestampRegexp.MatchString(v) {
+		return v
+	}
+	if generated {
+		return "(" + v + ")"
+	}
+	if len(v) >= 2 && v[0] == '\'' && v[len(v)-1] == '\'' {
+		return v // MariaDB reports quoted literals
+	}
+	return "'" + strings.ReplaceAll(v, "'", "''") + "'"
 }
 
 func (sc *Schema[T]) createSchema(ctx context.Context) error {
@@ -86,22 +179,7 @@ func (sc *Schema[T]) createSchema(ctx context.Context) error {
 
 	sql = "CREATE TABLE IF NOT EXISTS `" + sc.Name + "` ("
 	for _, field := range sc.Fields {
-		sql += "`" + field.Name + "` " + field.Type
-		if field.IsNullable {
-			sql += " NULL"
-		} else {
-			sql += " NOT NULL"
-		}
-		if field.IsAutoIncrement {
-			sql += " AUTO_INCREMENT"
-		}
-		if field.DefaultValue != "" {
-			sql += " DEFAULT " + field.DefaultValue
-		}
-		if field.Comment != "" {
-			sql += " COMMENT '" + escape(field.Comment) + "'"
-		}
-		sql += ","
+		sql += "`" + field.Name + "` " + field.sqlDefinition() + ","
 	}
 	for _, index := range sc.Indices {
 		if index.Primary {
@@ -116,19 +194,42 @@ func (sc *Schema[T]) createSchema(ctx context.Context) error {
 		}
 		sql = sql[:len(sql)-1] + "),"
 	}
+	for _, check := range sc.Checks {
+		sql += "CONSTRAINT `" + check.Name + "` CHECK (" + check.Expr + "),"
+	}
 	sql = sql[:len(sql)-1] + ")"
 	if sc.Engine != "" {
 		sql += " ENGINE=" + sc.Engine
 	}
 
+	if sc.Charset != "" {
+		sql += " DEFAULT CHARSET=" + sc.Charset
+	}
+
 	if sc.Collate != "" {
 		sql += " COLLATE=" + sc.Collate
 	}
 
+	if sc.RowFormat != "" {
+		sql += " ROW_FORMAT=" + sc.RowFormat
+	}
+
+	if sc.AutoIncrement != 0 {
+		sql += " AUTO_INCREMENT=" + strconv.FormatUint(sc.AutoIncrement, 10)
+	}
+
+	if sc.Compression != "" {
+		sql += " COMPRESSION='" + escape(sc.Compression) + "'"
+	}
+
 	if sc.Comment != "" {
 		sql += " COMMENT='" + escape(sc.Comment) + "'"
 	}
 
+	if sc.Partition != nil {
+		sql += " " + sc.Partition.sqlDefinition(time.Now())
+	}
+
 	_, err = sc.dbWrite.Ctx.ExecContext(ctx, sql, args...)
 	if err != nil {
 		return err
@@ -145,18 +246,36 @@ func (sc *Schema[T]) updateSchema(ctx context.Context) error {
 		}
 		return e
 	}
+	if cur.isView {
+		return errors.New("Cannot migrate view " + sc.Name + ", use NewUnmanagedSchema")
+	}
+	if sc.migration == MigrateCreateOnly {
+		return nil
+	}
 
 	sql := ""
 	args := make([]interface{}, 0, 10)
 
-	if sc.Engine != cur.Engine {
+	if !strings.EqualFold(sc.Engine, cur.Engine) {
 		sql += " ENGINE = " + sc.Engine
 	}
 
-	if sc.Collate != cur.Collate {
+	if sc.Charset != "" && sc.Charset != cur.Charset {
+		sql += " DEFAULT CHARSET = " + sc.Charset
+	}
+
+	if sc.Collate != "" && sc.Collate != cur.Collate {
 		sql += " COLLATE = " + sc.Collate
 	}
 
+	if sc.RowFormat != "" && !strings.EqualFold(sc.RowFormat, cur.RowFormat) {
+		sql += " ROW_FORMAT = " + sc.RowFormat
+	}
+
+	if sc.Compression != "" && !strings.EqualFold(sc.Compression, cur.Compression) && !(strings.EqualFold(sc.Compression, "none") && cur.Compression == "") {
+		sql += " COMPRESSION = '" + escape(sc.Compression) + "'"
+	}
+
 	if sc.Comment != cur.Comment {
 		sql += " COMMENT = '" + escape(sc.Comment) + "'"
 	}
@@ -169,39 +288,82 @@ func (sc *Schema[T]) updateSchema(ctx context.Context) error {
 		}
 	}
 
+	// Checks are dropped before columns, they may refer to the columns being dropped
+	for _, check := range cur.Checks {
+		if ck := sc.Check(check.Name); (ck == nil && sc.migration != MigrateAdditive) || (ck != nil && !ck.Equal(check)) {
+			sql = "ALTER TABLE `" + sc.Name + "` DROP CHECK `" + check.Name + "`"
+			_, e = sc.dbWrite.Ctx.ExecContext(ctx, sql, args...)
+			if e != nil {
+				return e
+			}
+		}
+	}
+
+	// Physical column order of the table, cur.Fields are loaded by ORDINAL_POSITION
+	order := make([]string, 0, len(cur.Fields))
 	for _, field := range cur.Fields {
-		if sc.Field(field.Name) == nil {
+		if sc.Field(field.Name) == nil && sc.migration != MigrateAdditive {
 			sql = "ALTER TABLE `" + sc.Name + "` DROP `" + field.Name + "`"
 			_, e = sc.dbWrite.Ctx.ExecContext(ctx, sql, args...)
 			if e != nil {
 				return e
 			}
+		} else {
+			order = append(order, field.Name)
 		}
 	}
 
-	for _, field := range sc.Fields {
+	for i, field := range sc.Fields {
 		fd := cur.Field(field.Name)
+		if fd != nil && fd.GeneratedType != field.GeneratedType && fd.GeneratedType != "STORED" && fd.GeneratedType != "" {
+			// A virtual column could not be made stored or plain in place, it keeps no data,
+			// drop it together with its indices, they will be recreated below
+			for i := 0; i < len(cur.Indices); i++ {
+				if cur.Indices[i].hasColumn(field.Name) {
+					sql = "ALTER TABLE `" + sc.Name + "` DROP INDEX `" + cur.Indices[i].Name + "`"
+					if cur.Indices[i].Primary {
+						sql = "ALTER TABLE `" + sc.Name + "` DROP PRIMARY KEY"
+					}
+					_, e = sc.dbWrite.Ctx.ExecContext(ctx, sql, args...)
+					if e != nil {
+						return e
+					}
+					cur.Indices = append(cur.Indices[:i], cur.Indices[i+1:]...)
+					i--
+				}
+			}
+			sql = "ALTER TABLE `" + sc.Name + "` DROP `" + field.Name + "`"
+			_, e = sc.dbWrite.Ctx.ExecContext(ctx, sql, args...)
+			if e != nil {
+				return e
+			}
+			order = removeString(order, field.Name)
+			fd = nil
+		}
+		// Columns before i are already in place when the order is kept
+		misplaced := sc.KeepColumnOrder && (i >= len(order) || order[i] != field.Name)
 		sql = ""
 		if fd == nil {
-			sql = "ALTER TABLE `" + sc.Name + "` ADD `" + field.Name + "` " + field.Type
-		} else if !fd.Equal(field) {
-			sql = "ALTER TABLE `" + sc.Name + "` MODIFY `" + field.Name + "` " + field.Type
-		}
-		if sql != "" {
-			if field.IsNullable {
-				sql += " NULL"
-			} else {
-				sql += " NOT NULL"
-			}
-			if field.IsAutoIncrement {
-				sql += " AUTO_INCREMENT"
+			sql = "ALTER TABLE `" + sc.Name + "` ADD `" + field.Name + "` " + field.sqlDefinition()
+		} else if !fd.Equal(field) || misplaced {
+			if fd.GeneratedType != field.GeneratedType && field.IsGenerated() {
+				// Stored generated columns could only be made plain in place, the data of the others would be lost
+				return errors.Wrap(ErrUnsafeMigration, "Column "+field.Name+" could not be changed from "+generatedState(fd)+" to "+generatedState(field))
 			}
-			if field.DefaultValue != "" {
-				sql += " DEFAULT " + field.DefaultValue
+			if isSerializedToScalar(fd, field) && !sc.converted[field.Name] {
+				return errors.Wrap(ErrUnsafeMigration, "Column "+field.Name+" keeps json data in "+fd.Type+", convert the data and open the schema WithConvertedColumns")
 			}
-			if field.Comment != "" {
-				sql += " COMMENT '" + escape(field.Comment) + "'"
+			sql = "ALTER TABLE `" + sc.Name + "` MODIFY `" + field.Name + "` " + field.sqlDefinition()
+		}
+		if sql != "" && sc.KeepColumnOrder {
+			if i == 0 {
+				sql += " FIRST"
+			} else {
+				sql += " AFTER `" + sc.Fields[i-1].Name + "`"
 			}
+			order = insertString(removeString(order, field.Name), i, field.Name)
+		}
+		if sql != "" {
 			_, e = sc.dbWrite.Ctx.ExecContext(ctx, sql, args...)
 			if e != nil {
 				return e
@@ -210,7 +372,7 @@ func (sc *Schema[T]) updateSchema(ctx context.Context) error {
 	}
 
 	for _, index := range cur.Indices {
-		if sc.Index(index.Name) == nil {
+		if sc.Index(index.Name) == nil && sc.migration != MigrateAdditive {
 			sql = "ALTER TABLE `" + sc.Name + "` DROP INDEX `" + index.Name + "`"
 			_, e = sc.dbWrite.Ctx.ExecContext(ctx, sql, args...)
 			if e != nil {
@@ -251,5 +413,15 @@ func (sc *Schema[T]) updateSchema(ctx context.Context) error {
 		}
 	}
 
-	return nil
+	for _, check := range sc.Checks {
+		if ck := cur.Check(check.Name); ck == nil || !ck.Equal(check) {
+			sql = "ALTER TABLE `" + sc.Name + "` ADD CONSTRAINT `" + check.Name + "` CHECK (" + check.Expr + ")"
+			_, e = sc.dbWrite.Ctx.ExecContext(ctx, sql, args...)
+			if e != nil {
+				return e
+			}
+		}
+	}
+
+	return sc.updatePartition(ctx, cur)
 }
@@ -0,0 +1,40 @@
+package mysql
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+)
+
+func TestIsSerializedToScalar(t *testing.T) {
+	type row struct {
+		Count    *int           `db:"count"`
+		Name     sql.NullString `db:"name varchar(32)"`
+		Note     string         `db:"note text"`
+		Body     string         `db:"body mediumblob"`
+		Tags     *[]string      `db:"tags"`
+		LongNote *string        `db:"long_note longtext"`
+	}
+	sc := &Schema[row]{Name: "t"}
+	if e := sc.fromType(reflect.TypeOf((*row)(nil))); e != nil {
+		t.Fatal(e)
+	}
+	cases := []struct {
+		column string
+		cur    string
+		want   bool
+	}{
+		{column: "count", cur: "mediumtext", want: true},
+		{column: "count", cur: "bigint(20)", want: false},
+		{column: "name", cur: "mediumtext", want: true},
+		{column: "note", cur: "mediumtext", want: false},
+		{column: "body", cur: "mediumtext", want: false},
+		{column: "tags", cur: "mediumtext", want: false},
+		{column: "long_note", cur: "mediumtext", want: false},
+	}
+	for _, c := range cases {
+		if got := isSerializedToScalar(&Field{Name: c.column, Type: c.cur}, sc.FieldsByColumn[c.column]); got != c.want {
+			t.Errorf("%s from %s: got %v, want %v", c.column, c.cur, got, c.want)
+		}
+	}
+}
@@ -6,15 +6,25 @@ import (
 	"github.com/pkg/errors"
 )
 
-func (sc *Schema[T]) DeleteEx(ctx context.Context, db IDBLike, where string, args ...any) (int64, error) {
+func (sc *Schema[T]) DeleteEx(ctx context.Context, db IDBLike, where any, args ...any) (int64, error) {
+	if sc.ReadOnly {
+		return 0, ErrReadOnly
+	}
 	if sc.dbRead == nil {
 		return 0, ErrNotReady
 	}
+	if isGrouped(where) {
+		return 0, errors.New("GROUP BY could not be used with Delete")
+	}
+	if q, ok := where.(*Query); ok && q != nil && q.offset > 0 {
+		return 0, errors.New("OFFSET could not be used with Delete")
+	}
 
-	s := "DELETE FROM `" + sc.Name + "`"
-	if where != "" {
-		s += " WHERE " + where
+	cond, tail, args, e := buildWhere(where, args, sc.FieldsByColumn, nil)
+	if e != nil {
+		return 0, e
 	}
+	s := "DELETE FROM `" + sc.Name + "`" + cond + tail
 
 	if r, e := db.ExecContext(ctx, s, args...); e != nil {
 		return 0, errors.Wrap(e, "SelectOne failed")
@@ -27,6 +37,9 @@ func (sc *Schema[T]) DeleteEx(ctx context.Context, db IDBLike, where string, arg
 	}
 }
 
-func (sc *Schema[T]) Delete(ctx context.Context, where string, args ...any) (int64, error) {
+func (sc *Schema[T]) Delete(ctx context.Context, where any, args ...any) (int64, error) {
+	if e := sc.checkWritable(); e != nil {
+		return 0, e
+	}
 	return sc.DeleteEx(ctx, sc.dbWrite.Ctx, where, args...)
 }
@@ -0,0 +1,140 @@
+package mysql
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ReEncrypt walks the table by primary key in batches, and re-encrypts the encrypted columns
+// which are not encrypted by the current key of the KeyProvider. Returns the number of rows updated.
+// A row modified during the job is left as it is, since it has been written with the current key.
+func (sc *Schema[T]) ReEncrypt(ctx context.Context, batchSize int) (int64, error) {
+	if sc.ReadOnly {
+		return 0, ErrReadOnly
+	}
+	fields := make([]*Field, 0)
+	for _, field := range sc.Fields {
+		if field.Encrypted {
+			fields = append(fields, field)
+		}
+	}
+	if len(fields) == 0 {
+		return 0, nil
+	}
+	if len(sc.primaryFields) == 0 {
+		return 0, ErrNoPrimaryKey
+	}
+	if batchSize < 1 {
+		batchSize = 1000
+	}
+	p, e := getKeyProvider()
+	if e != nil {
+		return 0, e
+	}
+	currentID, _, e := p.CurrentKey()
+	if e != nil {
+		return 0, errors.Wrap(e, "Get current key failed")
+	}
+
+	pkColumns := make([]string, len(sc.primaryFields))
+	for i, field := range sc.primaryFields {
+		pkColumns[i] = "`" + field.Name + "`"
+	}
+	columns := append([]string{}, pkColumns...)
+	for _, field := range fields {
+		columns = append(columns, "`"+field.Name+"`")
+	}
+	selectCmd := "SELECT " + strings.Join(columns, ",") + " FROM `" + sc.Name + "`"
+	orderCmd := " ORDER BY " + strings.Join(pkColumns, ",") + " LIMIT ?"
+	afterCmd := " WHERE (" + strings.Join(pkColumns, ",") + ") > (" + strings.Repeat("?,", len(pkColumns)-1) + "?)"
+
+	var updated int64
+	var last []interface{}
+	for {
+		s := selectCmd
+		args := make([]interface{}, 0, len(last)+1)
+		if last != nil {
+			s += afterCmd
+			args = append(args, last...)
+		}
+		args = append(args, batchSize)
+		rows, e := sc.dbWrite.Ctx.QueryContext(ctx, s+orderCmd, args...)
+		if e != nil {
+			return updated, errors.Wrap(e, "ReEncrypt select failed")
+		}
+		type row struct {
+			pk     []interface{}
+			values [][]byte
+		}
+		batch := make([]*row, 0, batchSize)
+		for rows.Next() {
+			r := &row{pk: make([]interface{}, len(pkColumns)), values: make([][]byte, len(fields))}
+			dest := make([]interface{}, 0, len(columns))
+			for i := range r.pk {
+				dest = append(dest, &r.pk[i])
+			}
+			for i := range r.values {
+				dest = append(dest, &r.values[i])
+			}
+			if e := rows.Scan(dest...); e != nil {
+				rows.Close()
+				return updated, errors.Wrap(e, "ReEncrypt scan failed")
+			}
+			batch = append(batch, r)
+		}
+		rows.Close()
+		if e := rows.Err(); e != nil {
+			return updated, errors.Wrap(e, "ReEncrypt select failed")
+		}
+
+		for _, r := range batch {
+			set := ""
+			setArgs := make([]interface{}, 0, len(fields))
+			where := ""
+			whereArgs := make([]interface{}, 0, len(fields))
+			for i, field := range fields {
+				if len(r.values[i]) == 0 {
+					continue
+				}
+				id, _, e := parseCipher(r.values[i])
+				if e != nil {
+					return updated, errors.Wrap(e, "ReEncrypt "+field.Name+" failed")
+				}
+				if id == currentID {
+					continue
+				}
+				plain, e := decryptValue(field.Name, r.values[i])
+				if e != nil {
+					return updated, errors.Wrap(e, "ReEncrypt "+field.Name+" failed")
+				}
+				v, e := encryptValue(field.Name, plain)
+				if e != nil {
+					return updated, errors.Wrap(e, "ReEncrypt "+field.Name+" failed")
+				}
+				set += "`" + field.Name + "` = ?,"
+				setArgs = append(setArgs, v)
+				where += " AND `" + field.Name + "` = ?"
+				whereArgs = append(whereArgs, r.values[i])
+			}
+			if set == "" {
+				continue
+			}
+			s := "UPDATE `" + sc.Name + "` SET " + set[:len(set)-1] + " WHERE " + sc.primaryWhere + where
+			args := append(append(setArgs, r.pk...), whereArgs...)
+			res, e := sc.dbWrite.Ctx.ExecContext(ctx, s, args...)
+			if e != nil {
+				return updated, errors.Wrap(e, "ReEncrypt update failed")
+			}
+			if n, e := res.RowsAffected(); e == nil {
+				updated += n
+			}
+		}
+
+		if len(batch) < batchSize {
+			return updated, nil
+		}
+		last = batch[len(batch)-1].pk
+	}
+}
@@ -2,11 +2,12 @@ package mysql
 
 import (
 	"reflect"
+	"strings"
 	"time"
 )
 
 // TAG FORMAT: `<name>` or `<name>(<value>)`
-// Value should surround by round brackets, the right bracket could be escaped by `\\`
+// Value should surround by round brackets, nested brackets are allowed, a single bracket could be escaped by `\\`
 
 /*
 The column information could be defined in the struct tag with the following format:
@@ -16,13 +17,24 @@ The options could be a set of the following:
 	pk						- Primary Key
 	ai						- Auto Increment
 	null					- Nullable
+	nullzero				- Scan NULL into a non-pointer field as its zero value, instead of failing
 	unsigned				- Unsigned
-	def(<value>)			- Default Value
+	def(<value>)			- Default Value, in SQL format, e.g. def(0), def('abc'), def(CURRENT_TIMESTAMP) or an expression def((UUID()))
+	onupdate(<value>)		- Value assigned on update, e.g. onupdate(CURRENT_TIMESTAMP)
+	gen(<expr>)				- Generated column with the given expression, could be followed by `virtual`(default) or `stored`
 	json					- Mark the column as json data
 	yaml					- Mark the column as yaml data
+	ser(<name>)				- Serialize the column by the serializer registered with the name, e.g. ser(gob), see Serializer
+	encrypt					- Encrypt the column by AES-GCM, with the keys from the KeyProvider, see SetKeyProvider
+	blind(<column>)			- Keep the HMAC of the value in an indexed companion column for equality lookups, see SelectBy
+							  the column name is optional, `<name>_bidx` will be used if omitted
 	unique(<index_name>)	- Mark the column as a part of unique index with the given index name
 	index(<index_name>)		- Mark the column as a part of index with the given index name
 	comment(<comment_text>) - Append comment for the field
+	charset(<charset>)		- Character set of the column, e.g. charset(latin1)
+	collate(<collation>)	- Collation of the column, e.g. collate(utf8mb4_bin)
+	check(<expr>)			- CHECK constraint on the column, e.g. check(balance >= 0), named as `<table>_chk_<column>`
+	expr(<expr>)			- Select the expression as the column, e.g. expr(SUM(amount)), only for the entities, see GetEntity
 
 The column_name could be omitted, if omitted, the field name will be used as column name and automatic convert to snake format.
 The column_type could be omitted, if omitted, the type will be determined by the field type, see below.
@@ -30,7 +42,20 @@ Only one primary key could exist in a table, if more than one column is marked a
 The index_name could be omitted, if omitted, the the column name with a prefix('idx_') will be used as index name.
 If more than one column is marked as a part of the same index, a composite index will be created.
 Only one index could be defined for a column, the `unique` and `index` option could NOT be used together.
+Generated columns are read only, they are skipped on insert and update, but could be selected and indexed as normal columns.
+A virtual column is recreated when it is made stored or plain, a stored one could only be made plain,
+other changes of the generated state fail with ErrUnsafeMigration since the data would be lost.
+Table options could be declared by the db tag of a blank field (`_`), see TableOptions.
+Unknown options are ignored by FromTag, the tags could be checked by ValidateStruct, by OpenSchema with WithStrict,
+or at `go vet` time by the analyzer in the dbtag package.
+
+Embedded structs (or pointers to structs) without a db tag are flattened, their fields become columns of the table.
+A nested struct field could be flattened as well with `db:"inline(<prefix>)"`, the prefix is prepended to the column
+names and the index names of its fields, e.g. `Addr Address `db:"inline(addr_)"`` gives `addr_city` for the `City` field.
 For compatibility reason, json column will be treated as text column in MySQL, and decode to json when query.
+Serialized columns are mediumtext by default, or mediumblob if the serializer gives binary data.
+Encrypted columns are blob by default, the field should be a string or []byte, values of a registered type are encoded
+by its ColumnType, other types are serialized to json before encryption.
 
 The column type could be one of the following:
 
@@ -62,6 +87,18 @@ The column type could be omitted, if omitted, the type will be determined by the
 	[]byte									- blob
 	time.Time								- datetime
 	other									- Serialized to json and stored as mediumtext in database
+
+Named types are mapped as their underlying kind, e.g. `type UserID int64` is mapped to bigint(20).
+Types implementing both sql.Scanner and driver.Valuer are passed to the driver directly, the column type must be declared or registered.
+Other types could be mapped by RegisterType, with optional converters.
+
+Pointer fields and sql.Null types (sql.NullString, sql.NullInt64, sql.Null[T], ...) are mapped as the underlying type,
+and the column is nullable automatically, sql.NullBool is mapped to tinyint(1). A nil pointer is written as NULL.
+Older versions serialized these fields to json mediumtext, such a column is not modified into the scalar type,
+the migration fails with ErrUnsafeMigration until the data is converted, e.g. for an int field:
+
+	UPDATE `t` SET `c` = NULLIF(`c`, 'null');
+	-- then OpenSchema with WithConvertedColumns("c"), string fields need JSON_UNQUOTE(`c`) as well
 */
 
 const (
@@ -69,8 +106,9 @@ const (
 	NONE = 0
 
 	// Serialize Types
-	JSON = 2
-	YAML = 3
+	JSON   = 2
+	YAML   = 3
+	CUSTOM = 4 // Serialized by Field.Serializer
 
 	// Index Types
 	INDEX       = 1
@@ -80,11 +118,13 @@ const (
 
 var (
 	timeTypeKind = reflect.TypeOf(time.Time{}).Kind()
+	timeType     = reflect.TypeOf(time.Time{})
 )
 
 type tagItem struct {
 	Name  string
 	Value string
+	Pos   int // Byte offset of the name in the tag
 }
 
 func jumpSpace(tag string, i int) int {
@@ -94,23 +134,29 @@ func jumpSpace(tag string, i int) int {
 	return i
 }
 
-func readValue(tag string, i int) (string, int) {
+func readValue(tag string, i int) (string, int, error) {
+	start := i - 1
 	o := ""
+	depth := 0
 	for i < len(tag) {
-		if tag[i] == ')' {
-			break
-		}
-		if tag[i] == '\\' {
+		if tag[i] == '(' {
+			depth++
+		} else if tag[i] == ')' {
+			if depth == 0 {
+				break
+			}
+			depth--
+		} else if tag[i] == '\\' {
 			i++
 		}
 		o += string(tag[i])
 		i++
 	}
 	if i >= len(tag) {
-		panic("tag format error (2): " + tag)
+		return "", i, &TagError{Tag: tag, Pos: start, Msg: "unclosed bracket"}
 	}
 	i++
-	return o, i
+	return o, i, nil
 }
 
 func readName(tag string, i int) (string, int) {
@@ -128,7 +174,7 @@ func readName(tag string, i int) (string, int) {
 	return o, i
 }
 
-func parseTagArguments(tag string) []tagItem {
+func parseTagArguments(tag string) ([]tagItem, error) {
 	var items []tagItem
 	i := 0
 	for i < len(tag) {
@@ -138,20 +184,151 @@ func parseTagArguments(tag string) []tagItem {
 		}
 		if tag[i] == '(' {
 			if len(items) == 0 {
-				panic("tag format error (1): " + tag)
+				return nil, &TagError{Tag: tag, Pos: i, Msg: "bracket without an option name"}
+			}
+			var e error
+			if items[len(items)-1].Value, i, e = readValue(tag, i+1); e != nil {
+				return nil, e
 			}
-			items[len(items)-1].Value, i = readValue(tag, i+1)
 		} else {
-			newItem := tagItem{}
+			newItem := tagItem{Pos: i}
 			newItem.Name, i = readName(tag, i)
 			items = append(items, newItem)
 		}
 	}
-	return items
+	return items, nil
 }
 
-func (fd *Field) FromTag(tag string, structField reflect.StructField) {
-	tagItems := parseTagArguments(tag)
+const (
+	tagArgNone     = iota // The option takes no value
+	tagArgOptional        // The value could be omitted
+	tagArgRequired        // The value is required
+)
+
+// fieldTagOption is an option of a column tag, apply returns the problem of the value if any
+type fieldTagOption struct {
+	arg   int
+	apply func(fd *Field, value string) string
+}
+
+// fieldTagOptions are the options of a column tag, used by FromTag and ValidateTag
+var fieldTagOptions = map[string]fieldTagOption{
+	"pk": {tagArgNone, func(fd *Field, value string) string {
+		fd.IsPrimaryKey = true
+		fd.Indices = append(fd.Indices, &FieldIndexDecl{IndexType: PRIMARY_KEY, IndexName: "PRIMARY"})
+		return ""
+	}},
+	"ai":       {tagArgNone, func(fd *Field, value string) string { fd.IsAutoIncrement = true; return "" }},
+	"null":     {tagArgNone, func(fd *Field, value string) string { fd.IsNullable = true; return "" }},
+	"nullzero": {tagArgNone, func(fd *Field, value string) string { fd.NullAsZero = true; return "" }},
+	"unsigned": {tagArgNone, func(fd *Field, value string) string {
+		if fd.Type == "" {
+			return "unsigned must follow a type"
+		}
+		fd.IsUnsigned = true
+		fd.Type += " unsigned"
+		return ""
+	}},
+	"def":      {tagArgRequired, func(fd *Field, value string) string { fd.DefaultValue = value; return "" }},
+	"onupdate": {tagArgRequired, func(fd *Field, value string) string { fd.OnUpdate = value; return "" }},
+	"gen": {tagArgRequired, func(fd *Field, value string) string {
+		fd.GeneratedExpr = value
+		if fd.GeneratedType == "" {
+			fd.GeneratedType = "VIRTUAL"
+		}
+		return ""
+	}},
+	"virtual": {tagArgNone, func(fd *Field, value string) string { fd.GeneratedType = "VIRTUAL"; return "" }},
+	"stored":  {tagArgNone, func(fd *Field, value string) string { fd.GeneratedType = "STORED"; return "" }},
+	"json":    {tagArgNone, func(fd *Field, value string) string { fd.SerializeMethod = JSON; return "" }},
+	"yaml":    {tagArgNone, func(fd *Field, value string) string { fd.SerializeMethod = YAML; return "" }},
+	"ser": {tagArgRequired, func(fd *Field, value string) string {
+		fd.Serializer = GetSerializer(value)
+		if fd.Serializer == nil {
+			return "unknown serializer " + value
+		}
+		fd.SerializeMethod = CUSTOM
+		return ""
+	}},
+	"encrypt": {tagArgNone, func(fd *Field, value string) string { fd.Encrypted = true; return "" }},
+	"blind": {tagArgOptional, func(fd *Field, value string) string {
+		fd.BlindIndex = value
+		if fd.BlindIndex == "" {
+			fd.BlindIndex = fd.Name + "_bidx"
+		}
+		return ""
+	}},
+	"unique": {tagArgOptional, func(fd *Field, value string) string {
+		if value == "" {
+			value = "idx_" + fd.Name
+		}
+		fd.Indices = append(fd.Indices, &FieldIndexDecl{IndexType: UNIQUE, IndexName: value})
+		return ""
+	}},
+	"index": {tagArgOptional, func(fd *Field, value string) string {
+		if value == "" {
+			value = "idx_" + fd.Name
+		}
+		fd.Indices = append(fd.Indices, &FieldIndexDecl{IndexType: INDEX, IndexName: value})
+		return ""
+	}},
+	"comment":    {tagArgOptional, func(fd *Field, value string) string { fd.Comment = value; return "" }},
+	"charset":    {tagArgRequired, func(fd *Field, value string) string { fd.Charset = value; return "" }},
+	"collate":    {tagArgRequired, func(fd *Field, value string) string { fd.Collate = value; return "" }},
+	"check":      {tagArgRequired, func(fd *Field, value string) string { fd.Check = value; return "" }},
+	"expr":       {tagArgRequired, func(fd *Field, value string) string { fd.Expr = value; return "" }},
+	"tinyint":    {tagArgOptional, columnTypeOption("tinyint", "4")},
+	"int":        {tagArgOptional, columnTypeOption("int", "11")},
+	"bigint":     {tagArgOptional, columnTypeOption("bigint", "20")},
+	"float":      {tagArgNone, columnTypeOption("float", "")},
+	"double":     {tagArgNone, columnTypeOption("double", "")},
+	"decimal":    {tagArgOptional, columnTypeOption("decimal", "32,8")},
+	"varchar":    {tagArgOptional, columnTypeOption("varchar", "64")},
+	"text":       {tagArgNone, columnTypeOption("text", "")},
+	"mediumtext": {tagArgNone, columnTypeOption("mediumtext", "")},
+	"longtext":   {tagArgNone, columnTypeOption("longtext", "")},
+	"blob":       {tagArgNone, columnTypeOption("blob", "")},
+	"mediumblob": {tagArgNone, columnTypeOption("mediumblob", "")},
+	"longblob":   {tagArgNone, columnTypeOption("longblob", "")},
+	"timestamp":  {tagArgNone, columnTypeOption("timestamp", "")},
+	"datetime":   {tagArgNone, columnTypeOption("datetime", "")},
+}
+
+// columnTypeOption declares the column type, with the length given by the value or the default one
+func columnTypeOption(columnType string, defaultLength string) func(fd *Field, value string) string {
+	return func(fd *Field, value string) string {
+		fd.Type = columnType
+		if value == "" {
+			value = defaultLength
+		}
+		if value != "" {
+			fd.Type += "(" + value + ")"
+		}
+		return ""
+	}
+}
+
+// hasTagOption reports whether the option is given in the items of a column tag, after the column name
+func hasTagOption(tagItems []tagItem, name string) bool {
+	for i, item := range tagItems {
+		if i > 0 && item.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// FromTag parses the db tag of the struct field, a *TagError is returned if the tag is malformed
+func (fd *Field) FromTag(tag string, structField reflect.StructField) error {
+	tagItems, e := parseTagArguments(tag)
+	if e != nil {
+		return tagError(e, structField.Name)
+	}
+	return fd.fromTagItems(tag, tagItems, structField)
+}
+
+// fromTagItems applies the items of the tag parsed by parseTagArguments, see FromTag
+func (fd *Field) fromTagItems(tag string, tagItems []tagItem, structField reflect.StructField) error {
 	for _, item := range tagItems {
 		if fd.Name == "" {
 			if item.Name == "." {
@@ -164,103 +341,66 @@ func (fd *Field) FromTag(tag string, structField reflect.StructField) {
 			}
 			continue
 		}
-		switch item.Name {
-		case "pk":
-			fd.IsPrimaryKey = true
-			fd.Indices = append(fd.Indices, &FieldIndexDecl{IndexType: PRIMARY_KEY, IndexName: "PRIMARY"})
-		case "ai":
-			fd.IsAutoIncrement = true
-		case "null":
-			fd.IsNullable = true
-		case "unsigned":
-			if fd.Type == "" {
-				panic("unsigned must follow a type")
-			}
-			fd.IsUnsigned = true
-			fd.Type += " unsigned"
-		case "def":
-			fd.DefaultValue = item.Value
-		case "json":
-			fd.SerializeMethod = JSON
-		case "yaml":
-			fd.SerializeMethod = YAML
-		case "unique":
-			if item.Value == "" {
-				item.Value = "idx_" + fd.Name
-			}
-			fd.Indices = append(fd.Indices, &FieldIndexDecl{IndexType: UNIQUE, IndexName: item.Value})
-		case "index":
-			if item.Value == "" {
-				item.Value = "idx_" + fd.Name
-			}
-			fd.Indices = append(fd.Indices, &FieldIndexDecl{IndexType: INDEX, IndexName: item.Value})
-		case "comment":
-			fd.Comment = item.Value
-		case "tinyint":
-			fd.Type = "tinyint"
-			if item.Value != "" {
-				fd.Type += "(" + item.Value + ")"
-			} else {
-				fd.Type += "(4)"
-			}
-		case "int":
-			fd.Type = "int"
-			if item.Value != "" {
-				fd.Type += "(" + item.Value + ")"
-			} else {
-				fd.Type += "(11)"
-			}
-		case "bigint":
-			fd.Type = "bigint"
-			if item.Value != "" {
-				fd.Type += "(" + item.Value + ")"
-			} else {
-				fd.Type += "(20)"
-			}
-		case "float":
-			fd.Type = "float"
-		case "double":
-			fd.Type = "double"
-		case "decimal":
-			fd.Type = "decimal"
-			if item.Value != "" {
-				fd.Type += "(" + item.Value + ")"
-			} else {
-				fd.Type += "(32,8)"
-			}
-		case "varchar":
-			fd.Type = "varchar"
-			if item.Value != "" {
-				fd.Type += "(" + item.Value + ")"
-			} else {
-				fd.Type += "(64)"
-			}
-		case "text":
-			fd.Type = "text"
-		case "mediumtext":
-			fd.Type = "mediumtext"
-		case "longtext":
-			fd.Type = "longtext"
-		case "blob":
-			fd.Type = "blob"
-		case "mediumblob":
-			fd.Type = "mediumblob"
-		case "longblob":
-			fd.Type = "longblob"
-		case "timestamp":
-			fd.Type = "timestamp"
-		case "datetime":
-			fd.Type = "datetime"
+		opt, ok := fieldTagOptions[item.Name]
+		if !ok {
+			continue // unknown options are reported by ValidateTag
+		}
+		if msg := opt.apply(fd, item.Value); msg != "" {
+			return &TagError{Field: structField.Name, Tag: tag, Pos: item.Pos, Msg: msg}
 		}
 	}
+	if fd.GeneratedType != "" && fd.GeneratedExpr == "" {
+		// never equal to the loaded column, it would be modified on every migration
+		return &TagError{Field: structField.Name, Tag: tag, Pos: strings.Index(tag, strings.ToLower(fd.GeneratedType)), Msg: strings.ToLower(fd.GeneratedType) + " requires gen"}
+	}
+	return nil
 }
 
-func (fd *Field) CompleteWithType(structField reflect.StructField) {
+// CompleteWithType completes the column type and serialization of the field from the type of the struct field,
+// a *TagError is returned if the column type could not be derived
+func (fd *Field) CompleteWithType(structField reflect.StructField) error {
 	if fd.Name == "" {
 		fd.Name = camelToSnake(structField.Name)
 	}
+	t := structField.Type
+	if ct := lookupType(t); ct != nil {
+		fd.completeWithColumnType(ct)
+		return nil
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+		fd.IsNullable = true
+		fd.nullType = true
+		if ct := lookupType(t); ct != nil {
+			fd.completeWithColumnType(ct)
+			return nil
+		}
+	}
+	if vt, ok := sqlNullValueType(t); ok {
+		t = vt
+		fd.IsNullable = true
+		fd.nullType = true
+		if t.Kind() == reflect.Bool && fd.Type == "" {
+			fd.Type = "tinyint(1)"
+		}
+	}
+	if fd.Encrypted {
+		if fd.SerializeMethod == NONE && t.Kind() != reflect.String && !(t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8) {
+			fd.SerializeMethod = JSON
+		}
+		if fd.Type == "" {
+			fd.Type = "blob"
+		}
+		return nil
+	}
+	if fd.Type == "" && fd.SerializeMethod != NONE {
+		fd.Type = "mediumtext"
+		if fd.serializer().Binary() {
+			fd.Type = "mediumblob"
+		}
+		return nil
+	}
 	if fd.Type == "" {
-		t := structField.Type
 		switch t.Kind() {
 		case reflect.Int8, reflect.Int16, reflect.Int32:
 			fd.Type = "int(11)"
@@ -268,8 +408,10 @@ func (fd *Field) CompleteWithType(structField reflect.StructField) {
 			fd.Type = "bigint(20)"
 		case reflect.Uint8, reflect.Uint16, reflect.Uint32:
 			fd.Type = "int(11) unsigned"
+			fd.IsUnsigned = true
 		case reflect.Uint, reflect.Uint64:
 			fd.Type = "bigint(20) unsigned"
+			fd.IsUnsigned = true
 		case reflect.Float32:
 			fd.Type = "float"
 		case reflect.Float64:
@@ -280,14 +422,54 @@ func (fd *Field) CompleteWithType(structField reflect.StructField) {
 			if t.Elem().Kind() == reflect.Uint8 {
 				fd.Type = "blob"
 			} else {
-				fd.Type = "mediumtext"
-				fd.SerializeMethod = JSON
+				return fd.completeWithOtherType(t, structField)
 			}
 		case timeTypeKind:
-			fd.Type = "datetime"
+			if t == timeType {
+				fd.Type = "datetime"
+			} else {
+				return fd.completeWithOtherType(t, structField)
+			}
 		default:
-			fd.Type = "mediumtext"
-			fd.SerializeMethod = JSON
+			return fd.completeWithOtherType(t, structField)
+		}
+	}
+	return nil
+}
+
+func (fd *Field) completeWithOtherType(t reflect.Type, structField reflect.StructField) error {
+	if isScannerValuer(t) {
+		if fd.Expr != "" {
+			return nil // scanned directly, an expression has no column type
+		}
+		// passed to the driver directly, a guessed column type would e.g. store decimals as strings
+		return &TagError{Tag: structField.Tag.Get("db"), Msg: "column type must be declared in the tag or registered with RegisterType"}
+	}
+	fd.Type = "mediumtext"
+	fd.SerializeMethod = JSON
+	return nil
+}
+
+func (fd *Field) completeWithColumnType(ct *ColumnType) {
+	if fd.Type == "" {
+		fd.Type = ct.Type
+		if fd.Encrypted {
+			fd.Type = "blob" // the encoded value is encrypted
 		}
 	}
+	if ct.Nullable {
+		fd.IsNullable = true
+	}
+	fd.columnType = ct
+}
+
+// sqlNullValueType returns the value type of sql.NullString, sql.NullInt64, sql.Null[T], etc.
+func sqlNullValueType(t reflect.Type) (reflect.Type, bool) {
+	if t.Kind() != reflect.Struct || t.PkgPath() != "database/sql" || !strings.HasPrefix(t.Name(), "Null") {
+		return nil, false
+	}
+	if t.NumField() != 2 || t.Field(1).Name != "Valid" {
+		return nil, false
+	}
+	return t.Field(0).Type, true
 }
@@ -1,5 +1,10 @@
 package mysql
 
+import (
+	"regexp"
+	"strings"
+)
+
 type FieldIndexDecl struct {
 	IndexType uint8  // pk | index | unique
 	IndexName string // index name
@@ -9,16 +14,33 @@ type Field struct {
 	// Basic information
 	Name            string // Column name
 	Type            string // Column type in SQL format
+	Charset         string // Character set of the column, the table default if empty
+	Collate         string // Collation of the column, the table default if empty
 	IsPrimaryKey    bool
 	IsAutoIncrement bool
 	IsNullable      bool
+	NullAsZero      bool // Scan NULL as the zero value of the field
 	IsUnsigned      bool
 	DefaultValue    string // Default value in SQL format
+	OnUpdate        string // ON UPDATE value in SQL format
+	GeneratedExpr   string // Expression of a generated column
+	GeneratedType   string // VIRTUAL | STORED
 	Comment         string
-	SerializeMethod uint8 // json | yaml | none
-	Indices         []*FieldIndexDecl
+	Check           string     // Expression of the column level CHECK constraint
+	Expr            string     // Expression selected instead of a column, only for projection entities, see GetEntity
+	SerializeMethod uint8      // json | yaml | custom | none
+	Serializer      Serializer // Serializer of custom method, set by ser(<name>)
+	// Encrypted by AES-GCM with the keys from the KeyProvider. Only the column name is bound as additional data,
+	// not the primary key, so a ciphertext copied to another row by someone with write access still decrypts there.
+	Encrypted  bool
+	BlindIndex string // Companion column keeping the HMAC of the value for equality lookups
+	Indices    []*FieldIndexDecl
+
+	EntityIndex []int // Index path in the entity, see reflect.Value.FieldByIndex
 
-	EntityIndex int // Index in the entity
+	columnType  *ColumnType // Registered type of the field, if any
+	blindSource *Field      // Source field if this is a blind index column
+	nullType    bool        // Pointer or sql.Null field, serialized to json by older versions
 }
 
 func (fd *Field) Equal(other *Field) bool {
@@ -28,21 +50,29 @@ func (fd *Field) Equal(other *Field) bool {
 	if fd.Type != other.Type {
 		return false
 	}
+	// Empty charset and collation mean the default of the table
+	if fd.Charset != "" && other.Charset != "" && fd.Charset != other.Charset {
+		return false
+	}
+	if fd.Collate != "" && other.Collate != "" && fd.Collate != other.Collate {
+		return false
+	}
 	if fd.IsNullable != other.IsNullable {
 		return false
 	}
 	if fd.IsAutoIncrement != other.IsAutoIncrement {
 		return false
 	}
-	defVal1 := fd.DefaultValue
-	defVal2 := other.DefaultValue
-	if defVal1 == "NULL" {
-		defVal1 = ""
+	if normalizeSQLExpr(fd.GeneratedExpr) != normalizeSQLExpr(other.GeneratedExpr) {
+		return false
+	}
+	if fd.GeneratedType != other.GeneratedType {
+		return false
 	}
-	if defVal2 == "NULL" {
-		defVal2 = ""
+	if normalizeSQLValue(fd.DefaultValue) != normalizeSQLValue(other.DefaultValue) {
+		return false
 	}
-	if defVal1 != defVal2 {
+	if normalizeSQLValue(fd.OnUpdate) != normalizeSQLValue(other.OnUpdate) {
 		return false
 	}
 	if fd.Comment != other.Comment {
@@ -50,3 +80,104 @@ func (fd *Field) Equal(other *Field) bool {
 	}
 	return true
 }
+
+// serializer returns the serializer of the column, or nil if the value is passed to the driver directly
+func (fd *Field) serializer() Serializer {
+	switch fd.SerializeMethod {
+	case JSON:
+		return GetSerializer("json")
+	case YAML:
+		return GetSerializer("yaml")
+	case CUSTOM:
+		return fd.Serializer
+	}
+	return nil
+}
+
+// IsGenerated reports whether the column is a generated column, which could not be written
+func (fd *Field) IsGenerated() bool {
+	return fd.GeneratedExpr != ""
+}
+
+// sqlDefinition returns the column definition used by CREATE TABLE and ALTER TABLE, without the column name
+func (fd *Field) sqlDefinition() string {
+	sql := fd.Type
+	if fd.Charset != "" {
+		sql += " CHARACTER SET " + fd.Charset
+	}
+	if fd.Collate != "" {
+		sql += " COLLATE " + fd.Collate
+	}
+	if fd.IsGenerated() {
+		sql += " GENERATED ALWAYS AS (" + fd.GeneratedExpr + ") " + fd.GeneratedType
+	}
+	if fd.IsNullable {
+		sql += " NULL"
+	} else {
+		sql += " NOT NULL"
+	}
+	if fd.IsAutoIncrement {
+		sql += " AUTO_INCREMENT"
+	}
+	if fd.DefaultValue != "" {
+		sql += " DEFAULT " + fd.DefaultValue
+	}
+	if fd.OnUpdate != "" {
+		sql += " ON UPDATE " + fd.OnUpdate
+	}
+	if fd.Comment != "" {
+		sql += " COMMENT '" + escape(fd.Comment) + "'"
+	}
+	return sql
+}
+
+var (
+	currentTimestampRegexp = regexp.MustCompile(`^(?i)(current_timestamp|now|localtime|localtimestamp)(\(\s*(\d*)\s*\))?$`)
+)
+
+// normalizeSQLValue converts a default or on update value into a canonical form,
+// so the value declared in the tag could be compared with the one reported by information_schema.
+//
+//	'abc', abc			-> abc
+//	NULL, ''				-> ''
+//	now(), CURRENT_TIMESTAMP	-> CURRENT_TIMESTAMP
+//	(UUID()), uuid()		-> (uuid())
+func normalizeSQLValue(v string) string {
+	v = strings.TrimSpace(v)
+	if v == "" || strings.EqualFold(v, "NULL") {
+		return ""
+	}
+	if len(v) >= 2 && v[0] == '\'' && v[len(v)-1] == '\'' {
+		v = v[1 : len(v)-1]
+		v = strings.ReplaceAll(v, "''", "'")
+		v = strings.ReplaceAll(v, "\\'", "'")
+		return v
+	}
+	if m := currentTimestampRegexp.FindStringSubmatch(v); m != nil {
+		if m[3] != "" && m[3] != "0" {
+			return "CURRENT_TIMESTAMP(" + m[3] + ")"
+		}
+		return "CURRENT_TIMESTAMP"
+	}
+	if len(v) >= 2 && v[0] == '(' && v[len(v)-1] == ')' {
+		return "(" + normalizeSQLExpr(v[1:len(v)-1]) + ")"
+	}
+	return v
+}
+
+var (
+	charsetIntroducerRegexp = regexp.MustCompile(`_[a-z0-9]+'`)
+)
+
+// normalizeSQLExpr converts an expression into a canonical form for comparison.
+// MySQL stores expressions in its own format, quoted identifiers, lower case function names
+// and charset introducers on string literals, they are all removed here.
+// Expressions should be declared in the format of `SHOW CREATE TABLE` when it matters,
+// e.g. json_unquote(json_extract(data,'$.name')) instead of data->>'$.name'.
+func normalizeSQLExpr(v string) string {
+	v = strings.ReplaceAll(v, "`", "")
+	v = strings.ReplaceAll(v, "\\'", "'")
+	v = strings.ToLower(strings.Join(strings.Fields(v), ""))
+	v = charsetIntroducerRegexp.ReplaceAllString(v, "'")
+	return v
+}
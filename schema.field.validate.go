@@ -0,0 +1,256 @@
+package mysql
+
+import (
+	"reflect"
+	"strconv"
+)
+
+// tableTagOptions are the options of the tag on a blank field, see TableOptions
+var tableTagOptions = map[string]int{
+	"engine":         tagArgRequired,
+	"charset":        tagArgRequired,
+	"collate":        tagArgRequired,
+	"comment":        tagArgOptional,
+	"row_format":     tagArgRequired,
+	"auto_increment": tagArgRequired,
+	"compression":    tagArgRequired,
+	"column_order":   tagArgNone,
+}
+
+var (
+	columnTypeOptions = map[string]bool{"tinyint": true, "int": true, "bigint": true, "float": true, "double": true, "decimal": true, "varchar": true, "text": true, "mediumtext": true, "longtext": true, "blob": true, "mediumblob": true, "longblob": true, "timestamp": true, "datetime": true}
+	integerTypes      = map[string]bool{"tinyint": true, "int": true, "bigint": true}
+	numericTypes      = map[string]bool{"tinyint": true, "int": true, "bigint": true, "float": true, "double": true, "decimal": true}
+)
+
+// ValidateTag checks the db tag of a column and returns all the problems found, with their positions in the tag.
+// kind is the kind of the field type, or of the element type for pointers, reflect.Invalid if unknown.
+// Unknown options, missing or unexpected values and conflicting options are reported,
+// the names of serializers are not checked here since they are registered at runtime.
+func ValidateTag(tag string, kind reflect.Kind) []*TagError {
+	items, e := parseTagArguments(tag)
+	if e != nil {
+		return []*TagError{e.(*TagError)}
+	}
+	problems := make([]*TagError, 0)
+	report := func(item tagItem, msg string) {
+		problems = append(problems, &TagError{Tag: tag, Pos: item.Pos, Msg: msg})
+	}
+	if len(items) == 0 {
+		return append(problems, &TagError{Tag: tag, Msg: "empty tag, use `.` for the default column name"})
+	}
+
+	name := items[0]
+	if name.Name == "inline" {
+		if kind != reflect.Invalid && kind != reflect.Struct {
+			report(name, "inline could only be used on struct fields")
+		}
+		for _, item := range items[1:] {
+			report(item, "inline could not be combined with other options")
+		}
+		return problems
+	}
+	if name.Value != "" {
+		report(name, "the first item should be the column name, use `.` for the default one")
+	} else if _, ok := fieldTagOptions[name.Name]; ok {
+		report(name, "the column name `"+name.Name+"` is an option, use `.` for the default column name")
+	}
+
+	seen := make(map[string]tagItem)
+	columnType := ""
+	serializers := make([]string, 0)
+	for _, item := range items[1:] {
+		opt, ok := fieldTagOptions[item.Name]
+		if !ok {
+			report(item, "unknown option "+item.Name)
+			continue
+		}
+		if prev, ok := seen[item.Name]; ok && item.Name != "index" && item.Name != "unique" {
+			report(item, "duplicate option "+item.Name+", first at "+strconv.Itoa(prev.Pos))
+		}
+		seen[item.Name] = item
+		if opt.arg == tagArgNone && item.Value != "" {
+			report(item, item.Name+" takes no value")
+		} else if opt.arg == tagArgRequired && item.Value == "" {
+			report(item, item.Name+" requires a value")
+		}
+
+		switch {
+		case columnTypeOptions[item.Name]:
+			if columnType != "" {
+				report(item, "column type declared more than once")
+			}
+			columnType = item.Name
+		case item.Name == "unsigned":
+			if columnType == "" {
+				report(item, "unsigned must follow a type")
+			} else if !numericTypes[columnType] {
+				report(item, "unsigned could not be used with "+columnType)
+			}
+		case item.Name == "json" || item.Name == "yaml" || item.Name == "ser":
+			serializers = append(serializers, item.Name)
+			if len(serializers) > 1 {
+				report(item, "only one of json, yaml and ser could be used")
+			}
+		}
+	}
+
+	conflict := func(a, b, msg string) {
+		if _, ok := seen[a]; ok {
+			if item, ok := seen[b]; ok {
+				report(item, msg)
+			}
+		}
+	}
+	conflict("pk", "null", "primary key could not be nullable")
+	conflict("ai", "def", "auto increment column could not have a default value")
+	conflict("ai", "null", "auto increment column could not be nullable")
+	conflict("gen", "def", "generated column could not have a default value")
+	conflict("gen", "ai", "generated column could not be auto increment")
+	conflict("gen", "onupdate", "generated column could not have an on update value")
+	conflict("virtual", "stored", "virtual and stored could not be used together")
+	conflict("unique", "index", "unique and index could not be used together")
+	conflict("encrypt", "pk", "encrypted column could not be the primary key")
+	conflict("encrypt", "unique", "encrypted column could not be indexed, use blind")
+	conflict("encrypt", "index", "encrypted column could not be indexed, use blind")
+	conflict("encrypt", "gen", "generated column could not be encrypted")
+	conflict("nullzero", "pk", "primary key could not be NULL")
+	for _, opt := range []string{"pk", "ai", "def", "onupdate", "gen", "encrypt", "unique", "index", "check"} {
+		conflict("expr", opt, opt+" could not be used with expr")
+	}
+
+	if item, ok := seen["ai"]; ok {
+		if columnType != "" && !integerTypes[columnType] {
+			report(item, "auto increment requires an integer column, got "+columnType)
+		} else if columnType == "" && kind != reflect.Invalid && !isIntegerKind(kind) {
+			report(item, "auto increment requires an integer field, got "+kind.String())
+		}
+	}
+	if item, ok := seen["blind"]; ok {
+		if _, ok := seen["encrypt"]; !ok {
+			report(item, "blind requires encrypt")
+		}
+	}
+	for _, opt := range []string{"virtual", "stored"} {
+		if item, ok := seen[opt]; ok {
+			if _, ok := seen["gen"]; !ok {
+				report(item, opt+" requires gen")
+			}
+		}
+	}
+	return problems
+}
+
+// ValidateTableTag checks the db tag of a blank field which declares the table options
+func ValidateTableTag(tag string) []*TagError {
+	items, e := parseTagArguments(tag)
+	if e != nil {
+		return []*TagError{e.(*TagError)}
+	}
+	problems := make([]*TagError, 0)
+	seen := make(map[string]bool)
+	for _, item := range items {
+		arg, ok := tableTagOptions[item.Name]
+		if !ok {
+			problems = append(problems, &TagError{Tag: tag, Pos: item.Pos, Msg: "unknown table option " + item.Name})
+			continue
+		}
+		if seen[item.Name] {
+			problems = append(problems, &TagError{Tag: tag, Pos: item.Pos, Msg: "duplicate option " + item.Name})
+		}
+		seen[item.Name] = true
+		if arg == tagArgNone && item.Value != "" {
+			problems = append(problems, &TagError{Tag: tag, Pos: item.Pos, Msg: item.Name + " takes no value"})
+		} else if arg == tagArgRequired && item.Value == "" {
+			problems = append(problems, &TagError{Tag: tag, Pos: item.Pos, Msg: item.Name + " requires a value"})
+		} else if _, e := strconv.ParseUint(item.Value, 10, 64); item.Name == "auto_increment" && e != nil {
+			problems = append(problems, &TagError{Tag: tag, Pos: item.Pos, Msg: "invalid auto_increment " + item.Value})
+		}
+	}
+	return problems
+}
+
+// ValidateStruct checks the db tags of all the fields of a struct type, including the flattened ones,
+// and the columns declared more than once. The problems are returned with the field names.
+func ValidateStruct(t reflect.Type) []*TagError {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	problems := make([]*TagError, 0)
+	if t.Kind() != reflect.Struct {
+		return append(problems, &TagError{Field: t.String(), Msg: "not a struct type"})
+	}
+	columns := make(map[string]string)
+	validateStruct(t, "", columns, map[reflect.Type]bool{t: true}, &problems)
+	return problems
+}
+
+func validateStruct(t reflect.Type, prefix string, columns map[string]string, visiting map[reflect.Type]bool, problems *[]*TagError) {
+	for i := 0; i < t.NumField(); i++ {
+		fieldType := t.Field(i)
+		fieldName := t.Name() + "." + fieldType.Name
+		tag, hasTag := fieldType.Tag.Lookup("db")
+		if inline, inlinePrefix, ok := inlineStruct(fieldType); ok {
+			if hasTag {
+				for _, p := range ValidateTag(tag, reflect.Struct) {
+					p.Field = fieldName
+					*problems = append(*problems, p)
+				}
+			}
+			if visiting[inline] {
+				*problems = append(*problems, &TagError{Field: fieldName, Tag: tag, Msg: "recursive struct"})
+				continue
+			}
+			visiting[inline] = true
+			validateStruct(inline, prefix+inlinePrefix, columns, visiting, problems)
+			delete(visiting, inline)
+			continue
+		}
+		if !hasTag || tag == "-" {
+			continue
+		}
+		if fieldType.Name == "_" {
+			for _, p := range ValidateTableTag(tag) {
+				p.Field = fieldName
+				*problems = append(*problems, p)
+			}
+			continue
+		}
+		ft := fieldType.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		fieldProblems := ValidateTag(tag, ft.Kind())
+		for _, p := range fieldProblems {
+			p.Field = fieldName
+			*problems = append(*problems, p)
+		}
+		if len(fieldProblems) > 0 {
+			continue
+		}
+		fd := &Field{}
+		if e := fd.FromTag(tag, fieldType); e != nil {
+			*problems = append(*problems, tagError(e, fieldName).(*TagError))
+			continue
+		}
+		if e := fd.CompleteWithType(fieldType); e != nil {
+			*problems = append(*problems, tagError(e, fieldName).(*TagError))
+			continue
+		}
+		column := prefix + fd.Name
+		if prev, ok := columns[column]; ok {
+			*problems = append(*problems, &TagError{Field: fieldName, Tag: tag, Msg: "column " + column + " is declared by " + prev + " as well"})
+		} else {
+			columns[column] = fieldName
+		}
+	}
+}
+
+func isIntegerKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
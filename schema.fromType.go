@@ -1,26 +1,92 @@
 package mysql
 
-import "reflect"
+import (
+	"reflect"
+	"strings"
 
-func (sc *Schema[T]) fromType(t reflect.Type) {
+	"github.com/pkg/errors"
+)
+
+func (sc *Schema[T]) fromType(t reflect.Type) error {
 	for t.Kind() == reflect.Ptr {
 		t = t.Elem()
 	}
 	if t.Kind() != reflect.Struct {
-		panic("FromType: t must be a struct type")
+		return errors.New("FromType: " + t.String() + " is not a struct type")
 	}
 	sc.Fields = make([]*Field, 0)
 	sc.primaryWhere = ""
+	if e := sc.fieldsFromType(t, nil, "", map[reflect.Type]bool{t: true}); e != nil {
+		return e
+	}
+	for _, field := range sc.Fields {
+		if field.BlindIndex != "" {
+			sc.Fields = append(sc.Fields, blindIndexField(field))
+		}
+	}
+	if len(sc.primaryWhere) > 5 {
+		sc.primaryWhere = sc.primaryWhere[:len(sc.primaryWhere)-5]
+	}
+	if e := sc.generateTableOptions(t); e != nil {
+		return e
+	}
+	sc.generateIndices()
+	sc.generateChecks(t)
+	sc.generatePartition(t)
+	sc.generateFieldMap()
+	entity, e := getEntity[T](sc)
+	if e != nil {
+		return e
+	}
+	sc.entity = entity
+	return nil
+}
+
+func (sc *Schema[T]) fieldsFromType(t reflect.Type, index []int, prefix string, visiting map[reflect.Type]bool) error {
 	for i := 0; i < t.NumField(); i++ {
 		fieldType := t.Field(i)
+		if inline, inlinePrefix, ok := inlineStruct(fieldType); ok {
+			if visiting[inline] {
+				return errors.New("FromType: recursive struct " + t.Name() + "." + fieldType.Name)
+			}
+			visiting[inline] = true
+			if e := sc.fieldsFromType(inline, appendIndex(index, i), prefix+inlinePrefix, visiting); e != nil {
+				return e
+			}
+			delete(visiting, inline)
+			continue
+		}
 		tag, ok := fieldType.Tag.Lookup("db")
-		if ok { // Only process fields with a db tag
+		if ok && fieldType.Name != "_" && tag != "-" { // Only process fields with a db tag
 			field := &Field{
 				Indices:     make([]*FieldIndexDecl, 0),
-				EntityIndex: i,
+				EntityIndex: appendIndex(index, i),
+			}
+			if e := field.FromTag(tag, fieldType); e != nil {
+				return tagError(e, t.Name()+"."+fieldType.Name)
+			}
+			if field.Expr != "" {
+				return &TagError{Field: t.Name() + "." + fieldType.Name, Tag: tag, Pos: strings.Index(tag, "expr("), Msg: "expr could only be used by entities"}
+			}
+			if e := field.CompleteWithType(fieldType); e != nil {
+				return tagError(e, t.Name()+"."+fieldType.Name)
+			}
+			if prefix != "" {
+				for _, idx := range field.Indices {
+					if idx.IndexType == PRIMARY_KEY {
+						continue
+					}
+					if idx.IndexName == "idx_"+field.Name {
+						idx.IndexName = "idx_" + prefix + field.Name
+					} else {
+						idx.IndexName = prefix + idx.IndexName
+					}
+				}
+				if field.BlindIndex != "" {
+					field.BlindIndex = prefix + field.BlindIndex
+				}
+				field.Name = prefix + field.Name
 			}
-			field.FromTag(tag, fieldType)
-			field.CompleteWithType(fieldType)
 			sc.Fields = append(sc.Fields, field)
 			if field.IsAutoIncrement {
 				sc.aiField = field
@@ -31,10 +97,5 @@ func (sc *Schema[T]) fromType(t reflect.Type) {
 			}
 		}
 	}
-	if len(sc.primaryWhere) > 5 {
-		sc.primaryWhere = sc.primaryWhere[:len(sc.primaryWhere)-5]
-	}
-	sc.generateIndices()
-	sc.generateFieldMap()
-	sc.entity = GetEntity[T](sc)
+	return nil
 }
@@ -1,16 +1,38 @@
 package mysql
 
-import "database/sql"
+import (
+	"database/sql"
+	"reflect"
+	"sync"
+)
 
 type Schema[T interface{}] struct {
 	Name           string
 	Fields         []*Field
 	Indices        []*Index
+	Checks         []*Check
+	Partition      *Partition
 	Engine         string
+	Charset        string
 	Collate        string
 	Comment        string
+	RowFormat      string
+	AutoIncrement  uint64
+	Compression    string
 	FieldsByColumn map[string]*Field
 
+	// Keep the physical column order the same as the struct fields when the table is migrated,
+	// columns are added and moved with FIRST / AFTER, as a table created from scratch
+	KeepColumnOrder bool
+
+	// Writes are rejected with ErrReadOnly, it is set for views by NewUnmanagedSchema
+	ReadOnly bool
+
+	unmanaged bool // DDL is never executed, see NewUnmanagedSchema
+	isView    bool
+	migration MigrationPolicy
+	converted map[string]bool // json columns which could be modified into a scalar type, see WithConvertedColumns
+
 	aiField *Field
 
 	dbWrite *DB
@@ -25,7 +47,9 @@ type Schema[T interface{}] struct {
 	primaryWhere    string
 	primaryFields   []*Field
 
-	entity *Entity[T]
+	entity     *Entity[T]
+	entities   map[reflect.Type]interface{} // *Entity[E] by E, see GetEntity
+	entitiesMu sync.RWMutex
 }
 
 func (sc *Schema[T]) Columns() []string {
@@ -45,6 +69,15 @@ func (sc *Schema[T]) Field(name string) *Field {
 	return nil
 }
 
+func (sc *Schema[T]) Check(name string) *Check {
+	for _, check := range sc.Checks {
+		if check.Name == name {
+			return check
+		}
+	}
+	return nil
+}
+
 func (sc *Schema[T]) Index(name string) *Index {
 	if name == "PRIMARY" {
 		name = ""
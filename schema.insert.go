@@ -4,28 +4,23 @@ import (
 	"context"
 	"reflect"
 
-	drv "github.com/go-sql-driver/mysql"
 	"github.com/pkg/errors"
 )
 
 func (sc *Schema[T]) InsertEx(ctx context.Context, db IDBLike, data *T) error {
+	if sc.ReadOnly {
+		return ErrReadOnly
+	}
 	val := reflect.ValueOf(data).Elem()
-	args := make([]any, len(sc.insertArgFields))
-	for i := 0; i < len(sc.insertArgFields); i++ {
-		f := sc.insertArgFields[i]
-		args[i] = SerializeField(f.SerializeMethod, val.Field(f.EntityIndex).Interface())
+	args, e := appendFieldValues(make([]any, 0, len(sc.insertArgFields)), sc.insertArgFields, val)
+	if e != nil {
+		return e
 	}
 
 	r, e := db.ExecContext(ctx, sc.insertCmd, args...)
 	//r, e := sc.insertStmt.ExecContext(ctx, args...)
 	if e != nil {
-		mysqlErr, ok := e.(*drv.MySQLError)
-		if ok {
-			if mysqlErr.Number == 1062 {
-				return ErrDuplicateKey
-			}
-		}
-		return errors.Wrap(e, "Insert failed")
+		return wrapExecError(e, "Insert failed")
 	}
 
 	if sc.aiField != nil {
@@ -34,9 +29,9 @@ func (sc *Schema[T]) InsertEx(ctx context.Context, db IDBLike, data *T) error {
 			return errors.Wrap(e, "Get last insert id failed")
 		}
 		if sc.aiField.IsUnsigned {
-			val.Field(sc.aiField.EntityIndex).SetUint(uint64(id))
+			fieldByIndex(val, sc.aiField.EntityIndex).SetUint(uint64(id))
 		} else {
-			val.Field(sc.aiField.EntityIndex).SetInt(id)
+			fieldByIndex(val, sc.aiField.EntityIndex).SetInt(id)
 		}
 	}
 
@@ -44,5 +39,8 @@ func (sc *Schema[T]) InsertEx(ctx context.Context, db IDBLike, data *T) error {
 }
 
 func (sc *Schema[T]) Insert(ctx context.Context, data *T) error {
+	if e := sc.checkWritable(); e != nil {
+		return e
+	}
 	return sc.InsertEx(ctx, sc.dbWrite.Ctx, data)
 }
@@ -3,40 +3,153 @@ package mysql
 import (
 	"context"
 	"reflect"
+	"strings"
+	"time"
 
 	"github.com/acsl-go/logger"
 	"github.com/pkg/errors"
 )
 
+// MigrationPolicy decides which DDL is executed when a schema is opened
+type MigrationPolicy uint8
+
+const (
+	MigrateAll        MigrationPolicy = iota // Create the table, add, modify and drop columns, indices and checks
+	MigrateAdditive                          // As MigrateAll, but the undeclared columns, indices and checks are never dropped
+	MigrateCreateOnly                        // Create the table if not exists, an existing table is left as it is
+	MigrateNone                              // No DDL at all, the table should exist
+)
+
+type schemaOptions struct {
+	ctx          context.Context
+	timeout      time.Duration
+	migration    MigrationPolicy
+	tableOptions *TableOptions
+	strict       bool
+	converted    []string
+}
+
+// SchemaOption is an option of OpenSchema
+type SchemaOption func(opts *schemaOptions)
+
+// WithContext sets the context of the migration, context.Background() by default
+func WithContext(ctx context.Context) SchemaOption {
+	return func(opts *schemaOptions) { opts.ctx = ctx }
+}
+
+// WithTimeout limits the time spent on the migration
+func WithTimeout(timeout time.Duration) SchemaOption {
+	return func(opts *schemaOptions) { opts.timeout = timeout }
+}
+
+// WithMigrationPolicy sets the migration policy, MigrateAll by default
+func WithMigrationPolicy(policy MigrationPolicy) SchemaOption {
+	return func(opts *schemaOptions) { opts.migration = policy }
+}
+
+// WithoutMigration skips the migration, same as WithMigrationPolicy(MigrateNone)
+func WithoutMigration() SchemaOption {
+	return WithMigrationPolicy(MigrateNone)
+}
+
+// WithTableOptions overrides the table options declared by the struct type
+func WithTableOptions(tableOptions *TableOptions) SchemaOption {
+	return func(opts *schemaOptions) { opts.tableOptions = tableOptions }
+}
+
+// WithStrict validates the db tags by ValidateStruct before the migration, and the struct against the table after it.
+// OpenSchema fails with TagErrors if any tag is invalid,
+// or with ErrSchemaMismatch if the table could not be read or written through the struct.
+func WithStrict() SchemaOption {
+	return func(opts *schemaOptions) { opts.strict = true }
+}
+
+// WithConvertedColumns allows the migration to modify the columns from the json mediumtext of a serialized field
+// into a scalar type, after their data is converted. It is refused otherwise, as the json values would be kept
+// in the new column, e.g. "abc" with the quotes, see the migration of pointer fields in FromTag.
+func WithConvertedColumns(columns ...string) SchemaOption {
+	return func(opts *schemaOptions) { opts.converted = append(opts.converted, columns...) }
+}
+
+// NewSchema opens the schema of T on the table, the process is terminated if it fails, see OpenSchema
 func NewSchema[T interface{}](dbr *DB, dbw *DB, name string) *Schema[T] {
+	schema, err := OpenSchema[T](dbr, dbw, name)
+	if err != nil {
+		logger.Fatal("%+v", err)
+		panic(err)
+	}
+	return schema
+}
+
+// OpenSchema maps T onto the table, the table is created or migrated according to the options.
+// Malformed db tags are returned as *TagError.
+func OpenSchema[T interface{}](dbr *DB, dbw *DB, name string, options ...SchemaOption) (*Schema[T], error) {
+	opts := &schemaOptions{ctx: context.Background()}
+	for _, option := range options {
+		option(opts)
+	}
+	ctx := opts.ctx
+	if opts.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.timeout)
+		defer cancel()
+	}
+
+	if opts.strict {
+		if problems := ValidateStruct(reflect.TypeOf((*T)(nil))); len(problems) > 0 {
+			return nil, TagErrors(problems)
+		}
+	}
+
 	schema := &Schema[T]{
-		Name:    name,
-		Engine:  "InnoDB",
-		Collate: "utf8mb4_general_ci",
-		dbRead:  dbr,
-		dbWrite: dbw,
+		Name:      name,
+		Engine:    "InnoDB",
+		Collate:   "utf8mb4_general_ci",
+		dbRead:    dbr,
+		dbWrite:   dbw,
+		migration: opts.migration,
 	}
-	schema.fromType(reflect.TypeOf((*T)(nil)))
-	if err := schema.updateSchema(context.Background()); err != nil {
-		logger.Fatal("%+v", errors.Wrap(err, "UpdateSchema Failed"))
-		panic(err)
+	for _, column := range opts.converted {
+		if schema.converted == nil {
+			schema.converted = make(map[string]bool)
+		}
+		schema.converted[column] = true
+	}
+	if err := schema.fromType(reflect.TypeOf((*T)(nil))); err != nil {
+		return nil, err
+	}
+	if opts.tableOptions != nil {
+		schema.applyTableOptions(opts.tableOptions)
+	}
+	if schema.migration != MigrateNone {
+		if err := schema.updateSchema(ctx); err != nil {
+			return nil, errors.Wrap(err, "UpdateSchema Failed")
+		}
+	}
+	if opts.strict {
+		mismatches, err := schema.Validate(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if len(mismatches) > 0 {
+			return nil, errors.Wrap(ErrSchemaMismatch, name+": "+strings.Join(mismatches, "; "))
+		}
 	}
 
-	if err := schema.init(); err != nil {
-		logger.Fatal("%+v", errors.Wrap(err, "Init Schema Failed"))
-		panic(err)
+	if err := schema.init(ctx); err != nil {
+		return nil, errors.Wrap(err, "Init Schema Failed")
 	}
 
-	return schema
+	return schema, nil
 }
 
-func (sc *Schema[T]) init() error {
+func (sc *Schema[T]) init(ctx context.Context) error {
 	var e error
 	sc.insertArgFields = make([]*Field, 0, len(sc.Fields))
 	sqla := "INSERT INTO `" + sc.Name + "` ("
 	sqlb := " VALUES ("
 	for _, field := range sc.Fields {
-		if field.IsAutoIncrement {
+		if field.IsAutoIncrement || field.IsGenerated() {
 			continue
 		}
 		sc.insertArgFields = append(sc.insertArgFields, field)
@@ -46,7 +159,7 @@ func (sc *Schema[T]) init() error {
 	sqla = sqla[:len(sqla)-1] + ")"
 	sqlb = sqlb[:len(sqlb)-1] + ")"
 	sc.insertCmd = sqla + sqlb
-	sc.insertStmt, e = sc.dbWrite.Ctx.Prepare(sc.insertCmd)
+	sc.insertStmt, e = sc.dbWrite.Ctx.PrepareContext(ctx, sc.insertCmd)
 	if e != nil {
 		return errors.Wrap(e, "Prepare insert failed")
 	}
@@ -54,7 +167,7 @@ func (sc *Schema[T]) init() error {
 	sc.updateAllFields = make([]*Field, 0, len(sc.Fields))
 	sqla = "UPDATE `" + sc.Name + "` SET "
 	for _, field := range sc.Fields {
-		if field.IsPrimaryKey {
+		if field.IsPrimaryKey || field.IsGenerated() {
 			continue
 		}
 		sc.updateAllFields = append(sc.updateAllFields, field)
@@ -62,7 +175,7 @@ func (sc *Schema[T]) init() error {
 	}
 	sqla = sqla[:len(sqla)-1] + " WHERE " + sc.primaryWhere
 	sc.updateAllCmd = sqla
-	sc.updateAllStmt, e = sc.dbWrite.Ctx.Prepare(sc.updateAllCmd)
+	sc.updateAllStmt, e = sc.dbWrite.Ctx.PrepareContext(ctx, sc.updateAllCmd)
 	if e != nil {
 		return errors.Wrap(e, "Prepare updateAll failed")
 	}
@@ -0,0 +1,106 @@
+package mysql
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// TableOptions are the options of a table, empty values are left as the defaults.
+// They could be declared by implementing TableOptioner on the struct type of a schema,
+// or by a db tag on a blank field, e.g.
+//
+//	_ struct{} `db:"engine(InnoDB) charset(utf8mb4) collate(utf8mb4_0900_ai_ci) comment(Orders) row_format(DYNAMIC) auto_increment(10000) compression(zlib) column_order"`
+type TableOptions struct {
+	Engine        string
+	Charset       string
+	Collate       string
+	Comment       string
+	RowFormat     string
+	AutoIncrement uint64 // Start value of the auto increment column, only applied when the table is created
+	Compression   string // InnoDB page compression, zlib | lz4 | none
+	ColumnOrder   bool   // Keep the column order of existing table in sync with the struct fields, see Schema.KeepColumnOrder
+}
+
+type TableOptioner interface {
+	TableOptions() *TableOptions
+}
+
+func tableOptionsFromTag(tag string) (*TableOptions, error) {
+	items, e := parseTagArguments(tag)
+	if e != nil {
+		return nil, e
+	}
+	opts := &TableOptions{}
+	for _, item := range items {
+		switch item.Name {
+		case "engine":
+			opts.Engine = item.Value
+		case "charset":
+			opts.Charset = item.Value
+		case "collate":
+			opts.Collate = item.Value
+		case "comment":
+			opts.Comment = item.Value
+		case "row_format":
+			opts.RowFormat = item.Value
+		case "auto_increment":
+			if opts.AutoIncrement, e = strconv.ParseUint(item.Value, 10, 64); e != nil {
+				return nil, &TagError{Tag: tag, Pos: strings.Index(tag, "auto_increment"), Msg: "invalid auto_increment " + item.Value}
+			}
+		case "compression":
+			opts.Compression = item.Value
+		case "column_order":
+			opts.ColumnOrder = true
+		}
+	}
+	return opts, nil
+}
+
+func (sc *Schema[T]) applyTableOptions(opts *TableOptions) {
+	if opts.Engine != "" {
+		sc.Engine = opts.Engine
+	}
+	if opts.Charset != "" {
+		sc.Charset = opts.Charset
+		if opts.Collate == "" && !strings.HasPrefix(sc.Collate, opts.Charset+"_") {
+			sc.Collate = "" // the default collation of the charset
+		}
+	}
+	if opts.Collate != "" {
+		sc.Collate = opts.Collate
+	}
+	if opts.Comment != "" {
+		sc.Comment = opts.Comment
+	}
+	if opts.RowFormat != "" {
+		sc.RowFormat = opts.RowFormat
+	}
+	if opts.AutoIncrement != 0 {
+		sc.AutoIncrement = opts.AutoIncrement
+	}
+	if opts.Compression != "" {
+		sc.Compression = opts.Compression
+	}
+	if opts.ColumnOrder {
+		sc.KeepColumnOrder = true
+	}
+}
+
+func (sc *Schema[T]) generateTableOptions(t reflect.Type) error {
+	for i := 0; i < t.NumField(); i++ {
+		if fieldType := t.Field(i); fieldType.Name == "_" {
+			if tag, ok := fieldType.Tag.Lookup("db"); ok {
+				opts, e := tableOptionsFromTag(tag)
+				if e != nil {
+					return tagError(e, t.Name()+"._")
+				}
+				sc.applyTableOptions(opts)
+			}
+		}
+	}
+	if optioner, ok := reflect.New(t).Interface().(TableOptioner); ok {
+		sc.applyTableOptions(optioner.TableOptions())
+	}
+	return nil
+}
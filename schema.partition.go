@@ -0,0 +1,160 @@
+package mysql
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/acsl-go/logger"
+	"github.com/pkg/errors"
+)
+
+func (sc *Schema[T]) loadPartition(ctx context.Context, dbName string) error {
+	sc.Partition = nil
+	rows, e := sc.dbWrite.Ctx.QueryContext(ctx, "SELECT `PARTITION_NAME`,`PARTITION_METHOD`,`PARTITION_EXPRESSION`,`PARTITION_DESCRIPTION` FROM `information_schema`.`PARTITIONS` WHERE `TABLE_SCHEMA` = ? AND `TABLE_NAME` = ? AND `PARTITION_NAME` IS NOT NULL ORDER BY `PARTITION_ORDINAL_POSITION`", dbName, sc.Name)
+	if e != nil {
+		return errors.Wrap(e, "Get table partitions failed")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name, method string
+		var expr, desc *string
+		if e := rows.Scan(&name, &method, &expr, &desc); e != nil {
+			return errors.Wrap(e, "Scan table partitions failed")
+		}
+		if sc.Partition == nil {
+			sc.Partition = &Partition{Type: method, Definitions: make([]*PartitionDef, 0)}
+			if expr != nil {
+				sc.Partition.Expr = *expr
+			}
+		}
+		if sc.Partition.Definition(name) != nil {
+			continue // sub partitions
+		}
+		def := &PartitionDef{Name: name}
+		if desc != nil {
+			if strings.HasPrefix(sc.Partition.method(), "LIST") {
+				def.Values = "IN (" + *desc + ")"
+			} else {
+				def.Values = "LESS THAN (" + *desc + ")"
+			}
+		}
+		sc.Partition.Definitions = append(sc.Partition.Definitions, def)
+		sc.Partition.Count++
+	}
+	return nil
+}
+
+func (sc *Schema[T]) updatePartition(ctx context.Context, cur *Schema[T]) error {
+	if sc.Partition == nil {
+		return nil // undeclared partitioning, e.g. made by hand, is left alone
+	}
+
+	if cur.Partition == nil || !sc.Partition.sameMethod(cur.Partition) || (sc.Partition.isHash() && sc.Partition.Count != cur.Partition.Count) {
+		_, e := sc.dbWrite.Ctx.ExecContext(ctx, "ALTER TABLE `"+sc.Name+"` "+sc.Partition.sqlDefinition(time.Now()))
+		return e
+	}
+
+	if sc.Partition.isHash() {
+		return nil
+	}
+
+	// Partitions are never dropped here, except by the retention of rotation
+	sql := ""
+	for _, def := range sc.Partition.Definitions {
+		if cur.Partition.Definition(def.Name) == nil {
+			sql += "PARTITION `" + def.Name + "` VALUES " + def.Values + ","
+		}
+	}
+	if sql != "" {
+		sql = "ALTER TABLE `" + sc.Name + "` ADD PARTITION (" + sql[:len(sql)-1] + ")"
+		if _, e := sc.dbWrite.Ctx.ExecContext(ctx, sql); e != nil {
+			return e
+		}
+	}
+
+	if sc.Partition.Rotation != nil {
+		return sc.RotatePartitions(ctx)
+	}
+	return nil
+}
+
+// RotatePartitions creates the partitions of the coming periods and drops the expired ones,
+// according to the Rotation of the declared Partition.
+func (sc *Schema[T]) RotatePartitions(ctx context.Context) error {
+	if sc.Partition == nil || sc.Partition.Rotation == nil {
+		return nil
+	}
+	if sc.unmanaged {
+		return ErrUnmanaged
+	}
+	r := sc.Partition.Rotation
+
+	var dbName string
+	if e := sc.dbWrite.Ctx.QueryRowContext(ctx, "SELECT DATABASE()").Scan(&dbName); e != nil {
+		return errors.Wrap(e, "Get database name failed")
+	}
+	cur := &Schema[T]{Name: sc.Name, dbWrite: sc.dbWrite}
+	if e := cur.loadPartition(ctx, dbName); e != nil {
+		return e
+	}
+	if cur.Partition == nil {
+		return errors.New("Table " + sc.Name + " is not partitioned")
+	}
+
+	now := time.Now()
+	last := time.Time{}
+	drops := ""
+	for _, def := range cur.Partition.Definitions {
+		start, ok := r.partitionStart(def.Name)
+		if !ok {
+			continue
+		}
+		if start.After(last) {
+			last = start
+		}
+		if r.Retention > 0 && start.Before(r.periodStart(now, -r.Retention)) {
+			drops += "`" + def.Name + "`,"
+		}
+	}
+
+	from := r.periodStart(now, 0)
+	if !last.IsZero() {
+		from = r.periodStart(last, 1)
+	}
+	sql := ""
+	for _, def := range r.definitions(from, r.periodStart(now, r.Ahead)) {
+		sql += "PARTITION `" + def.Name + "` VALUES " + def.Values + ","
+	}
+	if sql != "" {
+		sql = "ALTER TABLE `" + sc.Name + "` ADD PARTITION (" + sql[:len(sql)-1] + ")"
+		if _, e := sc.dbWrite.Ctx.ExecContext(ctx, sql); e != nil {
+			return errors.Wrap(e, "Add partitions failed")
+		}
+	}
+
+	if drops != "" {
+		sql = "ALTER TABLE `" + sc.Name + "` DROP PARTITION " + drops[:len(drops)-1]
+		if _, e := sc.dbWrite.Ctx.ExecContext(ctx, sql); e != nil {
+			return errors.Wrap(e, "Drop partitions failed")
+		}
+	}
+	return nil
+}
+
+// RunPartitionRotation calls RotatePartitions every interval until the context is done, errors are logged.
+func (sc *Schema[T]) RunPartitionRotation(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if e := sc.RotatePartitions(ctx); e != nil {
+			logger.Error("%+v", errors.Wrap(e, "RotatePartitions of "+sc.Name+" failed"))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
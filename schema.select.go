@@ -2,34 +2,166 @@ package mysql
 
 import (
 	"context"
+	"iter"
+	"reflect"
+
+	"github.com/pkg/errors"
 )
 
-func (sc *Schema[T]) SelectOne(ctx context.Context, where string, args ...any) (*T, error) {
+func (sc *Schema[T]) SelectOne(ctx context.Context, where any, args ...any) (*T, error) {
 	return sc.entity.SelectOne(ctx, where, args...)
 }
 
-func (sc *Schema[T]) Select(ctx context.Context, where string, args ...any) ([]*T, error) {
+func (sc *Schema[T]) Select(ctx context.Context, where any, args ...any) ([]*T, error) {
 	return sc.entity.Select(ctx, where, args...)
 }
 
-// SelectPage selects a page of records from the database.
+// SelectPage selects a page of records from the database with the exact total count.
 // page_idx shoud be 1-based.
-// Return: records, current page index, page size, page count, total count, error
-func (sc *Schema[T]) SelectPage(ctx context.Context, page_idx, page_size int64, where string, args ...any) ([]*T, int64, int64, int64, int64, error) {
+func (sc *Schema[T]) SelectPage(ctx context.Context, page_idx, page_size int64, where any, args ...any) (*Page[T], error) {
 	return sc.entity.SelectPage(ctx, page_idx, page_size, where, args...)
 }
 
-func (sc *Schema[T]) SelectOneEx(ctx context.Context, db IDBLike, where string, args ...any) (*T, error) {
+func (sc *Schema[T]) SelectOneEx(ctx context.Context, db IDBLike, where any, args ...any) (*T, error) {
 	return sc.entity.SelectOneEx(ctx, db, where, args...)
 }
 
-func (sc *Schema[T]) SelectEx(ctx context.Context, db IDBLike, where string, args ...any) ([]*T, error) {
+func (sc *Schema[T]) SelectEx(ctx context.Context, db IDBLike, where any, args ...any) ([]*T, error) {
 	return sc.entity.SelectEx(ctx, db, where, args...)
 }
 
-// SelectPage selects a page of records from the database.
+// SelectPage selects a page of records from the database with the exact total count.
 // page_idx shoud be 1-based.
-// Return: records, current page index, page size, page count, total count, error
-func (sc *Schema[T]) SelectPageEx(ctx context.Context, db IDBLike, page_idx, page_size int64, where string, args ...any) ([]*T, int64, int64, int64, int64, error) {
+func (sc *Schema[T]) SelectPageEx(ctx context.Context, db IDBLike, page_idx, page_size int64, where any, args ...any) (*Page[T], error) {
 	return sc.entity.SelectPageEx(ctx, db, page_idx, page_size, where, args...)
 }
+
+// SelectPageWith selects a page of records from the database, the total count is got by the strategy,
+// see Entity.SelectPageWithEx.
+func (sc *Schema[T]) SelectPageWith(ctx context.Context, count CountStrategy, page_idx, page_size int64, where any, args ...any) (*Page[T], error) {
+	return sc.entity.SelectPageWith(ctx, count, page_idx, page_size, where, args...)
+}
+
+func (sc *Schema[T]) SelectPageWithEx(ctx context.Context, db IDBLike, count CountStrategy, page_idx, page_size int64, where any, args ...any) (*Page[T], error) {
+	return sc.entity.SelectPageWithEx(ctx, db, count, page_idx, page_size, where, args...)
+}
+
+// SelectCursor selects a page of records by keyset pagination, see Entity.SelectCursorEx.
+// Return: records, cursor of the next page, cursor of the previous page, error
+func (sc *Schema[T]) SelectCursor(ctx context.Context, cursor string, size int64, where any, args ...any) ([]*T, string, string, error) {
+	return sc.entity.SelectCursor(ctx, cursor, size, where, args...)
+}
+
+func (sc *Schema[T]) SelectCursorEx(ctx context.Context, db IDBLike, cursor string, size int64, where any, args ...any) ([]*T, string, string, error) {
+	return sc.entity.SelectCursorEx(ctx, db, cursor, size, where, args...)
+}
+
+// Iter returns an iterator streaming the records, see Entity.IterEx
+func (sc *Schema[T]) Iter(ctx context.Context, reuse bool, where any, args ...any) iter.Seq2[*T, error] {
+	return sc.entity.Iter(ctx, reuse, where, args...)
+}
+
+func (sc *Schema[T]) IterEx(ctx context.Context, db IDBLike, reuse bool, where any, args ...any) iter.Seq2[*T, error] {
+	return sc.entity.IterEx(ctx, db, reuse, where, args...)
+}
+
+// ForEach calls fn with the records streamed one by one, see Entity.ForEachEx
+func (sc *Schema[T]) ForEach(ctx context.Context, reuse bool, fn func(*T) error, where any, args ...any) error {
+	return sc.entity.ForEach(ctx, reuse, fn, where, args...)
+}
+
+func (sc *Schema[T]) ForEachEx(ctx context.Context, db IDBLike, reuse bool, fn func(*T) error, where any, args ...any) error {
+	return sc.entity.ForEachEx(ctx, db, reuse, fn, where, args...)
+}
+
+// SelectOneBy selects a record by the value of a column,
+// the lookup on a column with blind index is rewritten to the blind index column.
+func (sc *Schema[T]) SelectOneBy(ctx context.Context, column string, value any) (*T, error) {
+	where, args, e := sc.EqCondition(column, value)
+	if e != nil {
+		return nil, e
+	}
+	return sc.entity.SelectOne(ctx, where, args...)
+}
+
+// SelectBy selects records by the value of a column,
+// the lookup on a column with blind index is rewritten to the blind index column.
+func (sc *Schema[T]) SelectBy(ctx context.Context, column string, value any) ([]*T, error) {
+	where, args, e := sc.EqCondition(column, value)
+	if e != nil {
+		return nil, e
+	}
+	return sc.entity.Select(ctx, where, args...)
+}
+
+// EqCondition returns the where condition and the arguments to look up records by the value of a column,
+// the value should be of the same type as the field. Lookups on a column with blind index are
+// rewritten to the blind index column, encrypted columns without blind index could not be looked up.
+func (sc *Schema[T]) EqCondition(column string, value any) (string, []any, error) {
+	field, ok := sc.FieldsByColumn[column]
+	if !ok {
+		return "", nil, errors.New("Unknown column: " + column)
+	}
+	return fieldEqCondition(field, value)
+}
+
+func fieldEqCondition(field *Field, value any) (string, []any, error) {
+	column := field.Name
+	if field.BlindIndex != "" {
+		column = field.BlindIndex
+	} else if field.Encrypted {
+		return "", nil, errors.New("Encrypted column without blind index: " + column)
+	}
+	if value == nil {
+		return "`" + column + "` IS NULL", nil, nil
+	}
+	if field.BlindIndex != "" {
+		h, e := blindIndexValue(field, reflect.ValueOf(value))
+		if e != nil {
+			return "", nil, e
+		}
+		return "`" + column + "` = ?", []any{h}, nil
+	}
+	arg, e := encodeFieldArg(field, value)
+	if e != nil {
+		return "", nil, e
+	}
+	return "`" + column + "` = ?", []any{arg}, nil
+}
+
+// encodeFieldArg converts a value compared with the column into the stored form,
+// by the encoder of the registered type or the serializer of the field.
+// Values of other types are passed as they are, e.g. the encoded values of a registered type.
+func encodeFieldArg(field *Field, value any) (any, error) {
+	if value == nil {
+		return nil, nil
+	}
+	if ct := field.columnType; ct != nil && ct.Encode != nil {
+		rv := reflect.ValueOf(value)
+		if rv.Kind() == reflect.Ptr && rv.Type() != ct.goType && rv.Type().Elem() == ct.goType {
+			if rv.IsNil() {
+				return nil, nil
+			}
+			rv = rv.Elem()
+		}
+		if rv.Type() != ct.goType {
+			return value, nil
+		}
+		r, e := ct.Encode(rv.Interface())
+		if e != nil {
+			return nil, errors.Wrap(e, "Encode "+field.Name+" failed")
+		}
+		return r, nil
+	}
+	if ser := field.serializer(); ser != nil {
+		b, e := ser.Marshal(value)
+		if e != nil {
+			return nil, errors.Wrap(e, "Serialize "+field.Name+" failed")
+		}
+		if ser.Binary() {
+			return b, nil
+		}
+		return string(b), nil
+	}
+	return value, nil
+}
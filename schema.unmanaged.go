@@ -0,0 +1,132 @@
+package mysql
+
+import (
+	"context"
+	"reflect"
+	"strings"
+
+	"github.com/acsl-go/logger"
+	"github.com/pkg/errors"
+)
+
+type ValidateMode uint8
+
+const (
+	ValidateNone   ValidateMode = iota // The struct is trusted
+	ValidateWarn                       // Mismatches are logged as warnings
+	ValidateStrict                     // Mismatches fail NewUnmanagedSchema with ErrSchemaMismatch
+)
+
+// NewUnmanagedSchema maps T onto an existing table or view without executing any DDL,
+// for the tables owned by others or the databases where only SELECT is granted.
+// The schema is read-only if readOnly is set or the target is a view, dbw could be nil then.
+// RotatePartitions returns ErrUnmanaged, and writes of a read-only schema return ErrReadOnly.
+func NewUnmanagedSchema[T interface{}](ctx context.Context, dbr *DB, dbw *DB, name string, validate ValidateMode, readOnly bool) (*Schema[T], error) {
+	schema := &Schema[T]{
+		Name:      name,
+		dbRead:    dbr,
+		dbWrite:   dbw,
+		ReadOnly:  readOnly,
+		unmanaged: true,
+	}
+	if e := schema.fromType(reflect.TypeOf((*T)(nil))); e != nil {
+		return nil, e
+	}
+
+	cur := &Schema[T]{Name: name, dbWrite: dbr}
+	if e := cur.loadSchema(ctx); e != nil {
+		if e == ErrNotFound {
+			return nil, errors.Wrap(e, "Table "+name)
+		}
+		return nil, errors.Wrap(e, "Load schema failed")
+	}
+	if cur.isView {
+		schema.isView = true
+		schema.ReadOnly = true
+	}
+
+	if validate != ValidateNone {
+		mismatches := schema.mismatches(cur)
+		for _, m := range mismatches {
+			if validate == ValidateWarn {
+				logger.Warn("%s: %s", name, m)
+			}
+		}
+		if validate == ValidateStrict && len(mismatches) > 0 {
+			return nil, errors.Wrap(ErrSchemaMismatch, name+": "+strings.Join(mismatches, "; "))
+		}
+	}
+
+	if !schema.ReadOnly {
+		if dbw == nil {
+			return nil, errors.New("Write database is required by writable schema " + name)
+		}
+		if e := schema.init(ctx); e != nil {
+			return nil, errors.Wrap(e, "Init Schema Failed")
+		}
+	}
+	return schema, nil
+}
+
+// Validate compares the struct with the table in the database, and returns the mismatches which break reads or writes
+func (sc *Schema[T]) Validate(ctx context.Context) ([]string, error) {
+	cur := &Schema[T]{Name: sc.Name, dbWrite: sc.dbRead}
+	if e := cur.loadSchema(ctx); e != nil {
+		return nil, errors.Wrap(e, "Load schema failed")
+	}
+	return sc.mismatches(cur), nil
+}
+
+func (sc *Schema[T]) mismatches(cur *Schema[T]) []string {
+	mismatches := make([]string, 0)
+	for _, field := range sc.Fields {
+		fd := cur.Field(field.Name)
+		if fd == nil {
+			mismatches = append(mismatches, "column "+field.Name+" not found")
+			continue
+		}
+		if typeFamily(field.Type) != typeFamily(fd.Type) {
+			mismatches = append(mismatches, "column "+field.Name+" is "+fd.Type+", expected "+field.Type)
+		}
+		if fd.IsNullable && !field.IsNullable && !field.NullAsZero && field.columnType == nil && field.serializer() == nil && !field.Encrypted {
+			mismatches = append(mismatches, "column "+field.Name+" is nullable, but the field could not hold NULL")
+		}
+	}
+	if sc.ReadOnly {
+		return mismatches
+	}
+	for _, fd := range cur.Fields {
+		if sc.FieldsByColumn[fd.Name] == nil && !fd.IsNullable && fd.DefaultValue == "" && !fd.IsAutoIncrement && !fd.IsGenerated() {
+			mismatches = append(mismatches, "column "+fd.Name+" is required by inserts, but not in the struct")
+		}
+	}
+	return mismatches
+}
+
+// typeFamily groups the column types which could be scanned into the same kinds of fields
+func typeFamily(columnType string) string {
+	switch t := baseType(columnType); t {
+	case "tinyint", "smallint", "mediumint", "int", "integer", "bigint", "bit", "year", "bool", "boolean":
+		return "integer"
+	case "float", "double", "real", "decimal", "numeric":
+		return "number"
+	case "char", "varchar", "binary", "varbinary", "tinytext", "text", "mediumtext", "longtext", "tinyblob", "blob", "mediumblob", "longblob", "json", "enum", "set":
+		return "string"
+	case "date", "datetime", "timestamp":
+		return "datetime"
+	default:
+		return t
+	}
+}
+
+// checkWritable returns ErrReadOnly for read-only schemas, which may have no write DB,
+// or ErrNotReady if the write DB is missing
+func (sc *Schema[T]) checkWritable() error {
+	if sc.ReadOnly {
+		return ErrReadOnly
+	}
+	if sc.dbWrite == nil {
+		return ErrNotReady
+	}
+	return nil
+}
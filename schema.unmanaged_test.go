@@ -0,0 +1,34 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestReadOnlyWrites(t *testing.T) {
+	type row struct {
+		ID   int64  `db:"id pk"`
+		Name string `db:"name"`
+	}
+	sc := &Schema[row]{Name: "users", ReadOnly: true, unmanaged: true}
+	if e := sc.fromType(reflect.TypeOf((*row)(nil))); e != nil {
+		t.Fatal(e)
+	}
+	ctx := context.Background()
+	data := &row{ID: 1, Name: "a"}
+	writes := map[string]func() error{
+		"Insert":   func() error { return sc.Insert(ctx, data) },
+		"Update":   func() error { _, e := sc.Update(ctx, data); return e },
+		"Delete":   func() error { _, e := sc.Delete(ctx, Eq("id", 1)); return e },
+		"InsertEx": func() error { return sc.InsertEx(ctx, nil, data) },
+		"UpdateEx": func() error { _, e := sc.UpdateEx(ctx, nil, data); return e },
+		"DeleteEx": func() error { _, e := sc.DeleteEx(ctx, nil, Eq("id", 1)); return e },
+	}
+	for name, write := range writes {
+		if e := write(); !errors.Is(e, ErrReadOnly) {
+			t.Errorf("%s: got %v, want %v", name, e, ErrReadOnly)
+		}
+	}
+}
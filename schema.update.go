@@ -4,13 +4,12 @@ import (
 	"context"
 	"reflect"
 
-	drv "github.com/go-sql-driver/mysql"
 	"github.com/pkg/errors"
 )
 
 func (sc *Schema[T]) UpdateEx(ctx context.Context, db IDBLike, data *T, columns ...string) (int64, error) {
-	if sc.dbWrite == nil {
-		return 0, ErrNotReady
+	if e := sc.checkWritable(); e != nil {
+		return 0, e
 	}
 
 	val := reflect.ValueOf(data).Elem()
@@ -18,22 +17,17 @@ func (sc *Schema[T]) UpdateEx(ctx context.Context, db IDBLike, data *T, columns
 
 	if len(columns) == 0 {
 		// Update all fields except primary key
-		for _, field := range sc.updateAllFields {
-			args = append(args, SerializeField(field.SerializeMethod, val.Field(field.EntityIndex).Interface()))
+		args, e := appendFieldValues(args, sc.updateAllFields, val)
+		if e != nil {
+			return 0, e
 		}
-		for _, field := range sc.primaryFields {
-			args = append(args, SerializeField(field.SerializeMethod, val.Field(field.EntityIndex).Interface()))
+		if args, e = appendFieldValues(args, sc.primaryFields, val); e != nil {
+			return 0, e
 		}
 		r, e := db.ExecContext(ctx, sc.updateAllCmd, args...)
 		//r, e := sc.updateAllStmt.ExecContext(ctx, args...)
 		if e != nil {
-			mysqlErr, ok := e.(*drv.MySQLError)
-			if ok {
-				if mysqlErr.Number == 1062 {
-					return 0, ErrDuplicateKey
-				}
-			}
-			return 0, errors.Wrap(e, "Update failed")
+			return 0, wrapExecError(e, "Update failed")
 		}
 		if n, e := r.RowsAffected(); e != nil {
 			return 0, errors.Wrap(e, "Get rows affected failed")
@@ -50,22 +44,31 @@ func (sc *Schema[T]) UpdateEx(ctx context.Context, db IDBLike, data *T, columns
 			if field.IsPrimaryKey {
 				return 0, errors.New("Cannot update primary key: " + column)
 			}
+			if field.IsGenerated() {
+				return 0, errors.New("Cannot update generated column: " + column)
+			}
 			s += "`" + column + "` = ?,"
-			args = append(args, SerializeField(field.SerializeMethod, val.Field(field.EntityIndex).Interface()))
+			v, e := serializeFieldValue(field, val)
+			if e != nil {
+				return 0, e
+			}
+			args = append(args, v)
+			if field.BlindIndex != "" {
+				s += "`" + field.BlindIndex + "` = ?,"
+				if v, e = serializeFieldValue(sc.FieldsByColumn[field.BlindIndex], val); e != nil {
+					return 0, e
+				}
+				args = append(args, v)
+			}
 		}
 		s = s[:len(s)-1] + " WHERE " + sc.primaryWhere
-		for _, field := range sc.primaryFields {
-			args = append(args, SerializeField(field.SerializeMethod, val.Field(field.EntityIndex).Interface()))
+		args, e := appendFieldValues(args, sc.primaryFields, val)
+		if e != nil {
+			return 0, e
 		}
 		r, e := db.ExecContext(ctx, s, args...)
 		if e != nil {
-			mysqlErr, ok := e.(*drv.MySQLError)
-			if ok {
-				if mysqlErr.Number == 1062 {
-					return 0, ErrDuplicateKey
-				}
-			}
-			return 0, errors.Wrap(e, "Update failed")
+			return 0, wrapExecError(e, "Update failed")
 		}
 		if n, e := r.RowsAffected(); e != nil {
 			return 0, errors.Wrap(e, "Get rows affected failed")
@@ -76,5 +79,8 @@ func (sc *Schema[T]) UpdateEx(ctx context.Context, db IDBLike, data *T, columns
 }
 
 func (sc *Schema[T]) Update(ctx context.Context, data *T, columns ...string) (int64, error) {
+	if e := sc.checkWritable(); e != nil {
+		return 0, e
+	}
 	return sc.UpdateEx(ctx, sc.dbWrite.Ctx, data, columns...)
 }
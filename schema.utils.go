@@ -1,5 +1,10 @@
 package mysql
 
+import (
+	"reflect"
+	"strconv"
+)
+
 func (sc *Schema[T]) generateIndices() {
 	sc.Indices = make([]*Index, 0)
 	for _, field := range sc.Fields {
@@ -24,6 +29,31 @@ func (sc *Schema[T]) generateIndices() {
 	}
 }
 
+func (sc *Schema[T]) generateChecks(t reflect.Type) {
+	sc.Checks = make([]*Check, 0)
+	for _, field := range sc.Fields {
+		if field.Check != "" {
+			sc.Checks = append(sc.Checks, &Check{Name: checkName(sc.Name, "chk_"+field.Name), Expr: field.Check})
+		}
+	}
+	if checker, ok := reflect.New(t).Interface().(TableChecker); ok {
+		for i, check := range checker.TableChecks() {
+			name := check.Name
+			if name == "" {
+				name = "chk_" + strconv.Itoa(i+1)
+			}
+			sc.Checks = append(sc.Checks, &Check{Name: checkName(sc.Name, name), Expr: check.Expr})
+		}
+	}
+}
+
+func (sc *Schema[T]) generatePartition(t reflect.Type) {
+	sc.Partition = nil
+	if partitioner, ok := reflect.New(t).Interface().(TablePartitioner); ok {
+		sc.Partition = partitioner.TablePartition()
+	}
+}
+
 func (sc *Schema[T]) generateFieldMap() {
 	sc.FieldsByColumn = make(map[string]*Field)
 	for _, field := range sc.Fields {
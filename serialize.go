@@ -1,31 +1,118 @@
 package mysql
 
 import (
-	"encoding/json"
+	"reflect"
 
-	"gopkg.in/yaml.v3"
+	"github.com/pkg/errors"
 )
 
+// SerializeField serializes data by JSON or YAML, "" is returned for other methods or if it fails.
+//
+// Deprecated: use Field.SerializeValue, which supports the custom serializers and returns the error.
 func SerializeField(t uint8, data interface{}) interface{} {
-	switch t {
-	case NONE:
-		return data
-	case JSON:
-		b, _ := json.Marshal(data)
-		return string(b)
-	case YAML:
-		b, _ := yaml.Marshal(data)
-		return string(b)
-	default:
+	if t == CUSTOM {
 		return ""
 	}
+	fd := &Field{SerializeMethod: t}
+	r, e := fd.SerializeValue(data)
+	if e != nil {
+		return ""
+	}
+	return r
 }
 
+// DeserializeField deserializes data by JSON or YAML, the error is ignored.
+//
+// Deprecated: use Field.DeserializeValue, which supports the custom serializers and returns the error.
 func DeserializeField(t uint8, data string, v *interface{}) {
-	switch t {
-	case JSON:
-		json.Unmarshal([]byte(data), v)
-	case YAML:
-		yaml.Unmarshal([]byte(data), v)
+	if t == JSON || t == YAML {
+		fd := &Field{SerializeMethod: t}
+		fd.DeserializeValue([]byte(data), v)
+	}
+}
+
+// SerializeValue serializes data as written to the column of the field, by its serializer if any:
+// a string for text serializers, []byte for binary ones, or data as it is if the field is not serialized.
+func (fd *Field) SerializeValue(data interface{}) (interface{}, error) {
+	ser := fd.serializer()
+	if ser == nil {
+		if fd.SerializeMethod == CUSTOM {
+			return nil, errors.New("Serializer is not set for the field " + fd.Name)
+		}
+		return data, nil
+	}
+	b, e := ser.Marshal(data)
+	if e != nil {
+		return nil, errors.Wrap(e, "Serialize "+fd.Name+" failed")
+	}
+	if ser.Binary() {
+		return b, nil
+	}
+	return string(b), nil
+}
+
+// DeserializeValue deserializes the data read from the column of the field into v, by its serializer
+func (fd *Field) DeserializeValue(data []byte, v interface{}) error {
+	ser := fd.serializer()
+	if ser == nil {
+		return errors.New("Field " + fd.Name + " is not serialized")
+	}
+	if e := ser.Unmarshal(data, v); e != nil {
+		return errors.Wrap(e, "Deserialize "+fd.Name+" failed")
+	}
+	return nil
+}
+
+// serializeFieldValue serializes the value of a field in data, nil values of a nullable column are written as NULL
+func serializeFieldValue(field *Field, data reflect.Value) (interface{}, error) {
+	v := fieldValueByIndex(data, field.EntityIndex)
+	if field.IsNullable {
+		switch v.Kind() {
+		case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+			if v.IsNil() {
+				return nil, nil
+			}
+		}
+	}
+	if field.blindSource != nil {
+		r, e := blindIndexValue(field.blindSource, v)
+		if e != nil {
+			return nil, e
+		}
+		return r, nil
+	}
+	if field.Encrypted {
+		plain, e := fieldPlainBytes(field, v)
+		if e != nil {
+			return nil, errors.Wrap(e, "Serialize "+field.Name+" failed")
+		}
+		r, e := encryptValue(field.Name, plain)
+		if e != nil {
+			return nil, errors.Wrap(e, "Encrypt "+field.Name+" failed")
+		}
+		return r, nil
+	}
+	if field.columnType != nil && field.columnType.Encode != nil {
+		if v.Kind() == reflect.Ptr && v.Type() != field.columnType.goType {
+			v = v.Elem() // registered as the element type of a pointer field
+		}
+		r, e := field.columnType.Encode(v.Interface())
+		if e != nil {
+			return nil, errors.Wrap(e, "Encode "+field.Name+" failed")
+		}
+		return r, nil
+	}
+	return field.SerializeValue(v.Interface())
+}
+
+// appendFieldValues appends the serialized values of the fields in data to args
+func appendFieldValues(args []interface{}, fields []*Field, data reflect.Value) ([]interface{}, error) {
+	for _, field := range fields {
+		v, e := serializeFieldValue(field, data)
+		if e != nil {
+			return nil, e
+		}
+		args = append(args, v)
 	}
+	return args, nil
 }
@@ -0,0 +1,57 @@
+package mysql
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSerializeValue(t *testing.T) {
+	type doc struct {
+		A int
+		B []string
+	}
+	data := doc{A: 1, B: []string{"x"}}
+	cases := []struct {
+		name  string
+		field *Field
+	}{
+		{name: "json", field: &Field{Name: "f", SerializeMethod: JSON}},
+		{name: "yaml", field: &Field{Name: "f", SerializeMethod: YAML}},
+		{name: "gob", field: &Field{Name: "f", SerializeMethod: CUSTOM, Serializer: GetSerializer("gob")}},
+		{name: "gzip json", field: &Field{Name: "f", SerializeMethod: CUSTOM, Serializer: GetSerializer("gzjson")}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			v, e := c.field.SerializeValue(data)
+			if e != nil {
+				t.Fatal(e)
+			}
+			var b []byte
+			switch x := v.(type) {
+			case string:
+				b = []byte(x)
+			case []byte:
+				b = x
+			default:
+				t.Fatalf("got %T, want string or []byte", v)
+			}
+			var got doc
+			if e := c.field.DeserializeValue(b, &got); e != nil {
+				t.Fatal(e)
+			}
+			if !reflect.DeepEqual(got, data) {
+				t.Errorf("got %+v, want %+v", got, data)
+			}
+		})
+	}
+
+	if _, e := (&Field{Name: "f", SerializeMethod: JSON}).SerializeValue(make(chan int)); e == nil {
+		t.Error("marshal error dropped")
+	}
+	if _, e := (&Field{Name: "f", SerializeMethod: CUSTOM}).SerializeValue(data); e == nil {
+		t.Error("custom method without serializer accepted")
+	}
+	if v, e := (&Field{Name: "f"}).SerializeValue(data); e != nil || !reflect.DeepEqual(v, data) {
+		t.Errorf("got %v %v, want the value as it is", v, e)
+	}
+}
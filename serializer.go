@@ -0,0 +1,108 @@
+package mysql
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/gob"
+	"encoding/json"
+	"io"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Serializer converts a field value into the data stored in the column and back,
+// it could be selected by `ser(<name>)` in the db tag after registered by RegisterSerializer.
+type Serializer interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+	// Binary reports whether the output is binary data, which should be stored in a blob column
+	Binary() bool
+}
+
+// Built-in serializers:
+//
+//	json	- encoding/json, text
+//	yaml	- gopkg.in/yaml.v3, text
+//	gob		- encoding/gob, binary
+//	gzjson	- gzip compressed json, binary
+var (
+	serializers = map[string]Serializer{
+		"json":   jsonSerializer{},
+		"yaml":   yamlSerializer{},
+		"gob":    gobSerializer{},
+		"gzjson": gzipJSONSerializer{},
+	}
+	serializersMu sync.RWMutex
+)
+
+// RegisterSerializer registers a serializer by name, the built-in ones could be replaced.
+func RegisterSerializer(name string, s Serializer) {
+	serializersMu.Lock()
+	defer serializersMu.Unlock()
+	serializers[name] = s
+}
+
+// GetSerializer returns the serializer registered by name, or nil if not found.
+func GetSerializer(name string) Serializer {
+	serializersMu.RLock()
+	defer serializersMu.RUnlock()
+	return serializers[name]
+}
+
+type jsonSerializer struct{}
+
+func (jsonSerializer) Marshal(v interface{}) ([]byte, error)      { return json.Marshal(v) }
+func (jsonSerializer) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+func (jsonSerializer) Binary() bool                               { return false }
+
+type yamlSerializer struct{}
+
+func (yamlSerializer) Marshal(v interface{}) ([]byte, error)      { return yaml.Marshal(v) }
+func (yamlSerializer) Unmarshal(data []byte, v interface{}) error { return yaml.Unmarshal(data, v) }
+func (yamlSerializer) Binary() bool                               { return false }
+
+type gobSerializer struct{}
+
+func (gobSerializer) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if e := gob.NewEncoder(&buf).Encode(v); e != nil {
+		return nil, e
+	}
+	return buf.Bytes(), nil
+}
+
+func (gobSerializer) Unmarshal(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+func (gobSerializer) Binary() bool { return true }
+
+type gzipJSONSerializer struct{}
+
+func (gzipJSONSerializer) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if e := json.NewEncoder(w).Encode(v); e != nil {
+		return nil, e
+	}
+	if e := w.Close(); e != nil {
+		return nil, e
+	}
+	return buf.Bytes(), nil
+}
+
+func (gzipJSONSerializer) Unmarshal(data []byte, v interface{}) error {
+	r, e := gzip.NewReader(bytes.NewReader(data))
+	if e != nil {
+		return e
+	}
+	defer r.Close()
+	b, e := io.ReadAll(r)
+	if e != nil {
+		return e
+	}
+	return json.Unmarshal(b, v)
+}
+
+func (gzipJSONSerializer) Binary() bool { return true }
@@ -0,0 +1,314 @@
+package mysql
+
+import (
+	"context"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ShardedSchema splits the records of T into tables named `<name>_<suffix>`, all with the same definition.
+// The suffix is derived from a time column, e.g. events_202610 for monthly shards, or from a key function.
+// Shards are created lazily by the first write routed to them, and migrated when they are first used.
+type ShardedSchema[T interface{}] struct {
+	Name             string
+	MigrationTimeout time.Duration // Limit of the DDL opening a shard, 1 minute if 0
+
+	period   *PartitionRotation // period and location of time shards, nil for key shards
+	column   *Field             // time column of time shards
+	key      func(data *T) string
+	dbRead   *DB
+	dbWrite  *DB
+	shards   map[string]*Schema[T]
+	opening  map[string]*shardOpening[T] // shards being opened, the DDL runs outside shardsMu
+	shardsMu sync.Mutex
+}
+
+const defaultShardTimeout = time.Minute
+
+// shardOpening is the result of a shard being opened, shared by the callers waiting for it
+type shardOpening[T interface{}] struct {
+	done chan struct{}
+	sc   *Schema[T]
+	err  error
+}
+
+// NewTimeShardedSchema creates a schema sharded by the period of a time column, the column should be a time.Time or *time.Time field.
+// Shards are named by the start of the period, e.g. events_20261019 (daily, weekly), events_202610 (monthly) and events_2026 (yearly).
+// loc is the time zone of the periods, time.Local if nil.
+func NewTimeShardedSchema[T interface{}](dbr *DB, dbw *DB, name string, column string, period PartitionPeriod, loc *time.Location) (*ShardedSchema[T], error) {
+	tpl := &Schema[T]{Name: name}
+	if e := tpl.fromType(reflect.TypeOf((*T)(nil))); e != nil {
+		return nil, e
+	}
+	field, ok := tpl.FieldsByColumn[column]
+	if !ok {
+		return nil, errors.New("Unknown shard column: " + column)
+	}
+	ft := reflect.TypeOf((*T)(nil)).Elem().FieldByIndex(field.EntityIndex).Type
+	if ft != timeType && ft != reflect.PointerTo(timeType) {
+		return nil, errors.New("Shard column should be time.Time: " + column)
+	}
+	return &ShardedSchema[T]{
+		Name:    name,
+		period:  &PartitionRotation{Period: period, Location: loc},
+		column:  field,
+		dbRead:  dbr,
+		dbWrite: dbw,
+		shards:  make(map[string]*Schema[T]),
+	}, nil
+}
+
+// NewKeyShardedSchema creates a schema sharded by the suffix returned by key, e.g. the tenant id of the record.
+// The suffix should consist of letters, digits and underscores, other suffixes are rejected with ErrInvalidData.
+func NewKeyShardedSchema[T interface{}](dbr *DB, dbw *DB, name string, key func(data *T) string) *ShardedSchema[T] {
+	return &ShardedSchema[T]{
+		Name:    name,
+		key:     key,
+		dbRead:  dbr,
+		dbWrite: dbw,
+		shards:  make(map[string]*Schema[T]),
+	}
+}
+
+// shardSuffix matches the suffixes which could be put into a table name without quoting problems
+var shardSuffix = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// Shard returns the schema of the shard with the suffix, the table is created if not exists.
+// The suffix should consist of letters, digits and underscores, and the table name should fit in 64 characters,
+// ErrInvalidData is returned otherwise.
+func (ss *ShardedSchema[T]) Shard(ctx context.Context, suffix string) (*Schema[T], error) {
+	if !shardSuffix.MatchString(suffix) || len(ss.Name)+1+len(suffix) > maxIdentifierLength {
+		return nil, errors.Wrap(ErrInvalidData, "invalid shard suffix: "+suffix)
+	}
+	ss.shardsMu.Lock()
+	if sc, ok := ss.shards[suffix]; ok {
+		ss.shardsMu.Unlock()
+		return sc, nil
+	}
+	if op, ok := ss.opening[suffix]; ok {
+		ss.shardsMu.Unlock()
+		select {
+		case <-op.done:
+			return op.sc, op.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	op := &shardOpening[T]{done: make(chan struct{})}
+	if ss.opening == nil {
+		ss.opening = make(map[string]*shardOpening[T])
+	}
+	ss.opening[suffix] = op
+	ss.shardsMu.Unlock()
+
+	// The DDL is shared by the callers waiting for the shard, it is not canceled with the ctx of this one
+	timeout := ss.MigrationTimeout
+	if timeout <= 0 {
+		timeout = defaultShardTimeout
+	}
+	op.sc, op.err = OpenSchema[T](ss.dbRead, ss.dbWrite, ss.Name+"_"+suffix, WithContext(context.WithoutCancel(ctx)), WithTimeout(timeout))
+	if op.err != nil {
+		op.sc, op.err = nil, errors.Wrap(op.err, "Open shard "+suffix+" failed")
+	}
+	ss.shardsMu.Lock()
+	delete(ss.opening, suffix)
+	if op.err == nil {
+		ss.shards[suffix] = op.sc
+	}
+	ss.shardsMu.Unlock()
+	close(op.done)
+	return op.sc, op.err
+}
+
+// SuffixAt returns the suffix of the time shard contains t
+func (ss *ShardedSchema[T]) SuffixAt(t time.Time) string {
+	if ss.period == nil {
+		return ""
+	}
+	return ss.period.periodStart(t, 0).Format(ss.period.nameFormat())
+}
+
+// SuffixOf returns the suffix of the shard that data belongs to
+func (ss *ShardedSchema[T]) SuffixOf(data *T) (string, error) {
+	if ss.key != nil {
+		suffix := ss.key(data)
+		if suffix == "" {
+			return "", errors.Wrap(ErrInvalidData, "empty shard key")
+		}
+		return suffix, nil
+	}
+	v := fieldValueByIndex(reflect.ValueOf(data).Elem(), ss.column.EntityIndex)
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return "", errors.Wrap(ErrInvalidData, "nil shard column: "+ss.column.Name)
+		}
+		v = v.Elem()
+	}
+	return ss.SuffixAt(v.Interface().(time.Time)), nil
+}
+
+// ShardOf returns the schema of the shard that data belongs to, the table is created if not exists
+func (ss *ShardedSchema[T]) ShardOf(ctx context.Context, data *T) (*Schema[T], error) {
+	suffix, e := ss.SuffixOf(data)
+	if e != nil {
+		return nil, e
+	}
+	return ss.Shard(ctx, suffix)
+}
+
+// Suffixes returns the suffixes of the existing shards in the database, in ascending order
+func (ss *ShardedSchema[T]) Suffixes(ctx context.Context) ([]string, error) {
+	prefix := ss.Name + "_"
+	rows, e := ss.dbRead.Ctx.QueryContext(ctx, "SELECT `TABLE_NAME` FROM `information_schema`.`TABLES` WHERE `TABLE_SCHEMA` = DATABASE() AND `TABLE_NAME` LIKE ?", escapeLike(prefix)+"%")
+	if e != nil {
+		return nil, errors.Wrap(e, "Get shards failed")
+	}
+	defer rows.Close()
+	suffixes := make([]string, 0)
+	for rows.Next() {
+		var name string
+		if e := rows.Scan(&name); e != nil {
+			return nil, errors.Wrap(e, "Scan shards failed")
+		}
+		suffix := strings.TrimPrefix(name, prefix)
+		if ss.period != nil {
+			if _, ok := ss.period.partitionStart("p" + suffix); !ok {
+				continue // not a shard, e.g. events_archive
+			}
+		}
+		suffixes = append(suffixes, suffix)
+	}
+	if e := rows.Err(); e != nil {
+		return nil, errors.Wrap(e, "Get shards failed")
+	}
+	sort.Strings(suffixes)
+	return suffixes, nil
+}
+
+func (ss *ShardedSchema[T]) Insert(ctx context.Context, data *T) error {
+	sc, e := ss.ShardOf(ctx, data)
+	if e != nil {
+		return e
+	}
+	return sc.Insert(ctx, data)
+}
+
+// Update updates the record in its shard, the shard column should not be changed,
+// or the record should be deleted from the old shard and inserted into the new one.
+func (ss *ShardedSchema[T]) Update(ctx context.Context, data *T, columns ...string) (int64, error) {
+	sc, e := ss.ShardOf(ctx, data)
+	if e != nil {
+		return 0, e
+	}
+	return sc.Update(ctx, data, columns...)
+}
+
+// SelectShard selects from the shard with the suffix, an empty result is returned if the shard does not exist
+func (ss *ShardedSchema[T]) SelectShard(ctx context.Context, suffix string, where any, args ...any) ([]*T, error) {
+	sc, e := ss.existingShard(ctx, suffix)
+	if e != nil || sc == nil {
+		return nil, e
+	}
+	return sc.Select(ctx, where, args...)
+}
+
+// SelectRange selects the records with the time column in [from, to) from the time shards covering the range.
+// where is an additional condition, a string with args, a Cond or a *Query without GROUP BY.
+// The ORDER BY, LIMIT and OFFSET of a *Query apply to the merged rows, see mergeRows.
+func (ss *ShardedSchema[T]) SelectRange(ctx context.Context, from, to time.Time, where any, args ...any) ([]*T, error) {
+	shards, cond, e := ss.rangeShards(ctx, from, to, where, args)
+	if e != nil {
+		return nil, e
+	}
+	shardCond, e := scatterWhere(cond)
+	if e != nil {
+		return nil, e
+	}
+	results := make([][]*T, 0, len(shards))
+	for _, sc := range shards {
+		rows, e := sc.Select(ctx, shardCond)
+		if e != nil {
+			return nil, e
+		}
+		results = append(results, rows)
+	}
+	if len(shards) == 0 {
+		return make([]*T, 0), nil
+	}
+	return mergeRows(results, cond, shards[0].FieldsByColumn)
+}
+
+// CountRange counts the records with the time column in [from, to), see SelectRange
+func (ss *ShardedSchema[T]) CountRange(ctx context.Context, from, to time.Time, where any, args ...any) (int64, error) {
+	if isGrouped(where) {
+		return 0, errors.New("GROUP BY could not be used across shards")
+	}
+	shards, cond, e := ss.rangeShards(ctx, from, to, where, args)
+	if e != nil {
+		return 0, e
+	}
+	var total int64
+	for _, sc := range shards {
+		n, e := sc.Count(ctx, cond)
+		if e != nil {
+			return 0, e
+		}
+		total += n
+	}
+	return total, nil
+}
+
+func (ss *ShardedSchema[T]) rangeShards(ctx context.Context, from, to time.Time, where any, args []any) ([]*Schema[T], any, error) {
+	if ss.period == nil {
+		return nil, nil, errors.New("Range select is only supported by time shards")
+	}
+	cond, e := andWhere(where, args, Raw("`"+ss.column.Name+"` >= ? AND `"+ss.column.Name+"` < ?", from, to))
+	if e != nil || !from.Before(to) {
+		return nil, nil, e
+	}
+	existing, e := ss.Suffixes(ctx)
+	if e != nil {
+		return nil, nil, e
+	}
+	first, last := ss.SuffixAt(from), ss.SuffixAt(to.Add(-time.Nanosecond))
+	shards := make([]*Schema[T], 0)
+	for _, suffix := range existing {
+		if suffix < first || suffix > last {
+			continue
+		}
+		sc, e := ss.Shard(ctx, suffix)
+		if e != nil {
+			return nil, nil, e
+		}
+		shards = append(shards, sc)
+	}
+	return shards, cond, nil
+}
+
+func (ss *ShardedSchema[T]) existingShard(ctx context.Context, suffix string) (*Schema[T], error) {
+	ss.shardsMu.Lock()
+	sc, ok := ss.shards[suffix]
+	ss.shardsMu.Unlock()
+	if ok {
+		return sc, nil
+	}
+	var n int
+	if e := ss.dbRead.Ctx.QueryRowContext(ctx, "SELECT COUNT(*) FROM `information_schema`.`TABLES` WHERE `TABLE_SCHEMA` = DATABASE() AND `TABLE_NAME` = ?", ss.Name+"_"+suffix).Scan(&n); e != nil {
+		return nil, errors.Wrap(e, "Get shards failed")
+	}
+	if n == 0 {
+		return nil, nil
+	}
+	return ss.Shard(ctx, suffix)
+}
+
+// escapeLike escapes the wildcards of LIKE patterns
+func escapeLike(s string) string {
+	return strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_").Replace(s)
+}
@@ -0,0 +1,20 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestShardSuffix(t *testing.T) {
+	type row struct {
+		ID int64 `db:"id pk"`
+	}
+	ss := NewKeyShardedSchema[row](nil, nil, "events", func(data *row) string { return "" })
+	for _, suffix := range []string{"", "a`b", "t1; DROP TABLE users", "x-y", "tenant.1", strings.Repeat("a", 58)} {
+		if _, e := ss.Shard(context.Background(), suffix); !errors.Is(e, ErrInvalidData) {
+			t.Errorf("%q: got %v, want %v", suffix, e, ErrInvalidData)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package mysql
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+	"sync"
+)
+
+// ColumnType describes how a Go type is stored in a column
+type ColumnType struct {
+	Type     string // Column type in SQL format, e.g. decimal(32,8), binary(16)
+	Nullable bool   // Make the column nullable by default
+
+	// Encode converts the value of the Go type into a value supported by the driver, optional
+	Encode func(v interface{}) (interface{}, error)
+	// Decode converts the value scanned from the driver (nil, int64, float64, bool, []byte, string or time.Time)
+	// into dest, which is a pointer to the Go type, optional
+	Decode func(src interface{}, dest interface{}) error
+
+	goType reflect.Type
+}
+
+var (
+	typeRegistry   = make(map[reflect.Type]*ColumnType)
+	typeRegistryMu sync.RWMutex
+
+	scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	valuerType  = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+)
+
+// RegisterType maps the Go type V to a column type, it applies to all schemas created afterwards.
+// Types implementing sql.Scanner and driver.Valuer are passed to the driver directly, their fields need
+// a column type declared in the tag or registered here, the converters are optional for them.
+func RegisterType[V interface{}](ct *ColumnType) {
+	typeRegistryMu.Lock()
+	defer typeRegistryMu.Unlock()
+	registered := *ct
+	registered.goType = reflect.TypeOf((*V)(nil)).Elem()
+	typeRegistry[registered.goType] = &registered
+}
+
+func lookupType(t reflect.Type) *ColumnType {
+	typeRegistryMu.RLock()
+	defer typeRegistryMu.RUnlock()
+	return typeRegistry[t]
+}
+
+// isScannerValuer reports whether the values of t could be passed to the driver and scanned back directly,
+// a type implementing only one of the interfaces is serialized to json instead
+func isScannerValuer(t reflect.Type) bool {
+	return t.Implements(valuerType) && reflect.PointerTo(t).Implements(scannerType)
+}